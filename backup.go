@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backups of replaced themes live in a hidden folder of the themes directory, with an extension
+// Warp does not load, named <theme file>.<timestamp>.bak. A generated theme's manifest entry is
+// kept next to its backup as <theme file>.<timestamp>.json.
+const (
+	backupDirName    = ".backups"
+	backupExt        = ".bak"
+	backupEntryExt   = ".json"
+	backupTimeFormat = "20060102-150405.000"
+)
+
+// themeBackup is a saved earlier version of a theme file
+type themeBackup struct {
+	Path     string    // Path of the backup itself
+	Filename string    // Name of the theme file it restores
+	Time     time.Time // When the theme file was replaced
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so readers such as Warp never see a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeAtomic(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeAtomic creates a temporary file in path's directory, fills it with write, flushes it to
+// disk and renames it to path. The temporary file is removed on failure.
+func writeAtomic(path string, perm os.FileMode, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+// backupThemeFile copies the theme file or background image at path to the backup folder before
+// it is replaced with data and returns the backup's path. Nothing is backed up, and the path is
+// empty, when the file does not exist or already holds data.
+func backupThemeFile(path string, data []byte) (string, error) {
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && bytes.Equal(existing, data)) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	backupDir := filepath.Join(filepath.Dir(path), backupDirName)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", err
+	}
+	backupPath := filepath.Join(backupDir, filepath.Base(path)+"."+time.Now().Format(backupTimeFormat)+backupExt)
+	if err := writeFileAtomic(backupPath, existing, 0644); err != nil {
+		return "", err
+	}
+	if err := saveBackupEntry(filepath.Dir(path), filepath.Base(path), backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// listBackups returns the backups in themesDir, newest first, limited to those of filename
+// unless it is empty
+func listBackups(themesDir, filename string) ([]themeBackup, error) {
+	entries, err := os.ReadDir(filepath.Join(themesDir, backupDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var backups []themeBackup
+	for _, entry := range entries {
+		backup, ok := parseBackupName(entry.Name())
+		if !ok || (filename != "" && backup.Filename != filename) {
+			continue
+		}
+		backup.Path = filepath.Join(themesDir, backupDirName, entry.Name())
+		backups = append(backups, backup)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// backupEntryPath returns where the manifest entry of the backup at backupPath is kept
+func backupEntryPath(backupPath string) string {
+	return strings.TrimSuffix(backupPath, backupExt) + backupEntryExt
+}
+
+// parseBackupName splits a backup's file name into the theme file it restores and its time
+func parseBackupName(name string) (themeBackup, bool) {
+	stem := strings.TrimSuffix(name, backupExt)
+	if stem == name || len(stem) <= len(backupTimeFormat)+1 {
+		return themeBackup{}, false
+	}
+
+	split := len(stem) - len(backupTimeFormat)
+	timestamp, err := time.ParseInLocation(backupTimeFormat, stem[split:], time.Local)
+	if err != nil || stem[split-1] != '.' {
+		return themeBackup{}, false
+	}
+	return themeBackup{Filename: stem[:split-1], Time: timestamp}, true
+}
+
+// restoreBackup puts a backup back in place in themesDir, itself backing up the current file
+// first so the restore can be undone, and tracks the restored file in the manifest when it was
+// generated. It returns the restored path and that new backup's path.
+func restoreBackup(themesDir string, backup themeBackup) (string, string, error) {
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	path := filepath.Join(themesDir, backup.Filename)
+	replaced, err := backupThemeFile(path, data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	if err := restoreBackupEntry(themesDir, backup); err != nil {
+		return "", "", err
+	}
+	return path, replaced, nil
+}
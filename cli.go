@@ -0,0 +1,630 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// Exit codes returned by non-interactive commands
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// commands maps subcommand names to their handlers; each returns the process exit code
+var commands = map[string]func(args []string) int{
+	"convert":   runConvert,
+	"list":      runList,
+	"restore":   runRestore,
+	"installed": runInstalled,
+	"uninstall": runUninstall,
+}
+
+// runConvert converts a single theme, or every matching theme with --all, without launching the interactive UI
+func runConvert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	themeQuery := flags.String("theme", "", "theme name, display name or path to a theme file")
+	outputDir := flags.String("out", "", "output directory (defaults to Warp's themes directory)")
+	convertAll := flags.Bool("all", false, "convert every discovered theme")
+	filter := flags.String("filter", "", "with --all, only convert themes whose display name contains this text")
+	themeType := flags.String("type", "", "with --all, only convert themes of this type (dark or light)")
+	source := flags.String("source", "", "only use themes from this editor (vscode, insiders, vscodium, cursor, windsurf or builtin)")
+	vsixPath := flags.String("vsix", "", "use the themes of a .vsix package instead of installed extensions")
+	contrastReport := flags.Bool("contrast-report", false, "print the contrast ratio of every ANSI color against the background")
+	var opts ConvertOptions
+	registerConversionFlags(flags, &opts)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	// A .vsix package with a single theme needs neither --theme nor --all
+	if (*convertAll && *themeQuery != "") || (!*convertAll && *themeQuery == "" && *vsixPath == "") {
+		fmt.Fprintln(os.Stderr, "Error: exactly one of --theme or --all is required")
+		flags.Usage()
+		return exitUsage
+	}
+
+	if *source != "" && !isEditorSource(*source) {
+		fmt.Fprintf(os.Stderr, "Error: unknown source %q\n", *source)
+		return exitUsage
+	}
+
+	if err := validatePlatformSupport(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	opts.OutputDir = *outputDir
+
+	// A path to an existing theme file is used directly
+	if stat, err := os.Stat(*themeQuery); *vsixPath == "" && err == nil && !stat.IsDir() {
+		themeInfo, err := parseThemeFile(*themeQuery)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to parse theme file %s: %v\n", *themeQuery, err)
+			return exitFailure
+		}
+		return runConvertOne(*themeInfo, opts, *contrastReport)
+	}
+
+	themes, cleanup, err := loadThemes(*vsixPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	defer cleanup()
+
+	if *convertAll {
+		return runConvertAll(matchThemes(themes, ThemeFilter{Text: *filter, Type: *themeType, Source: *source}), opts)
+	}
+
+	if *themeQuery == "" {
+		if len(themes) != 1 {
+			fmt.Fprintf(os.Stderr, "Error: %s contains %d themes, use --theme or --all:\n%s\n", *vsixPath, len(themes), formatThemeNames(themes))
+			return exitUsage
+		}
+		return runConvertOne(themes[0], opts, *contrastReport)
+	}
+
+	themeInfo, err := resolveTheme(matchThemes(themes, ThemeFilter{Source: *source}), *themeQuery)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	return runConvertOne(*themeInfo, opts, *contrastReport)
+}
+
+// runConvertOne converts a single theme and prints where it was saved, optionally with a full contrast report
+func runConvertOne(themeInfo ThemeInfo, opts ConvertOptions, contrastReport bool) int {
+	result := convertWithPrompt(themeInfo, opts, bufio.NewReader(os.Stdin))
+	printWarnings(result)
+	if result.Err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", result.Err)
+		return exitFailure
+	}
+	if result.Conflict != "" {
+		fmt.Printf("Skipped '%s': %s belongs to another theme\n", themeInfo.DisplayName, result.Conflict)
+		return exitOK
+	}
+
+	fmt.Printf("Converted '%s' to %s\n", themeInfo.DisplayName, result.Path)
+	if result.Backup != "" {
+		fmt.Printf("  previous version backed up to %s\n", result.Backup)
+	}
+	fmt.Printf("  background: %s, foreground: %s\n", result.Report.BackgroundKey, result.Report.ForegroundKey)
+	if len(result.Report.Overrides) > 0 {
+		fmt.Printf("  overrides: %s\n", strings.Join(result.Report.Overrides, ", "))
+	}
+	if contrastReport {
+		printContrastReport(result.Report.Contrast)
+	} else {
+		printContrastRepairs(result.Report.Contrast)
+	}
+	return exitOK
+}
+
+// runConvertAll converts every given theme and prints a report
+func runConvertAll(themes []ThemeInfo, opts ConvertOptions) int {
+	if len(themes) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no themes matched")
+		return exitFailure
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	results := make([]ConversionResult, len(themes))
+	for i, themeInfo := range themes {
+		results[i] = convertWithPrompt(themeInfo, opts, stdin)
+	}
+	for _, result := range results {
+		printWarnings(result)
+		if result.Err != nil {
+			fmt.Printf("✗ %s: %v\n", result.Theme.DisplayName, result.Err)
+		} else if result.Conflict != "" {
+			fmt.Printf("– %s: skipped, %s belongs to another theme\n", result.Theme.DisplayName, result.Conflict)
+		} else {
+			fmt.Printf("✓ %s → %s\n", result.Theme.DisplayName, result.Path)
+			if result.Backup != "" {
+				fmt.Printf("  previous version backed up to %s\n", result.Backup)
+			}
+			printContrastRepairs(result.Report.Contrast)
+		}
+	}
+
+	failed, skipped := countFailures(results), countSkipped(results)
+	fmt.Printf("\nConverted %d of %d themes (%d skipped, %d failed)\n", len(results)-failed-skipped, len(results), skipped, failed)
+	if failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// convertWithPrompt converts a theme and, when its file belongs to another theme under the prompt
+// policy, asks on stderr what to do and converts it again accordingly
+func convertWithPrompt(themeInfo ThemeInfo, opts ConvertOptions, in *bufio.Reader) ConversionResult {
+	result := convertThemeInfo(themeInfo, opts)
+	if result.Conflict == "" || opts.OnConflict != conflictPrompt {
+		return result
+	}
+	opts.OnConflict = promptConflictPolicy(in, os.Stderr, themeInfo.DisplayName, result.Conflict)
+	return convertThemeInfo(themeInfo, opts)
+}
+
+// printWarnings reports a conversion's warnings on stderr
+func printWarnings(result ConversionResult) {
+	for _, warning := range result.warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", result.Theme.DisplayName, warning)
+	}
+}
+
+// printContrastReport prints every ANSI slot with its contrast ratio against the background
+func printContrastReport(checks []ContrastCheck) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  SLOT\tCOLOR\tCONTRAST\tREPAIRED FROM")
+	for _, check := range checks {
+		fmt.Fprintf(tw, "  %s\t%s\t%.2f:1\t%s\n", check.Slot, check.Color, check.Ratio, check.Original)
+	}
+	tw.Flush()
+}
+
+// printContrastRepairs prints the ANSI slots whose colors were changed to meet the contrast target
+func printContrastRepairs(checks []ContrastCheck) {
+	for _, check := range checks {
+		if check.Original != "" {
+			fmt.Printf("  repaired %s: %s → %s (%.2f:1)\n", check.Slot, check.Original, check.Color, check.Ratio)
+		}
+	}
+}
+
+// loadThemes returns the themes of the .vsix package at vsixPath, or the installed themes when it is empty.
+// cleanup releases any temporary files and must be called once the themes are no longer needed.
+func loadThemes(vsixPath string) (themes []ThemeInfo, cleanup func(), err error) {
+	if vsixPath != "" {
+		return LoadVSIXThemes(vsixPath)
+	}
+
+	themes, err = DiscoverVSCodeThemes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover VS Code themes: %w", err)
+	}
+	return themes, func() {}, nil
+}
+
+// resolveTheme finds the single theme among themes referred to by query
+func resolveTheme(themes []ThemeInfo, query string) (*ThemeInfo, error) {
+	matches := findThemes(themes, query)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no theme matching %q found", query)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches %d themes, use the display name or --source to pick one:\n%s", query, len(matches), formatThemeNames(matches))
+	}
+}
+
+// formatThemeNames lists the display names of themes, one indented name per line
+func formatThemeNames(themes []ThemeInfo) string {
+	names := make([]string, len(themes))
+	for i, theme := range themes {
+		names[i] = "  " + theme.DisplayName
+	}
+	return strings.Join(names, "\n")
+}
+
+// findThemes returns the themes whose label, display name or file name equals query (case-insensitive)
+func findThemes(themes []ThemeInfo, query string) []ThemeInfo {
+	var matches []ThemeInfo
+	for _, theme := range themes {
+		if strings.EqualFold(theme.DisplayName, query) {
+			// A display name match is unambiguous
+			return []ThemeInfo{theme}
+		}
+		if strings.EqualFold(theme.Label, query) || strings.EqualFold(theme.Name, query) {
+			matches = append(matches, theme)
+		}
+	}
+	return matches
+}
+
+// themeListing is the machine-readable description of a discovered theme printed by the list command
+type themeListing struct {
+	Name             string `json:"name"`
+	DisplayName      string `json:"display_name"`
+	Type             string `json:"type"`
+	Path             string `json:"path"`
+	Source           string `json:"source"`
+	ExtensionID      string `json:"extension_id,omitempty"`
+	ExtensionVersion string `json:"extension_version,omitempty"`
+	Converted        bool   `json:"converted"`
+}
+
+// runList prints every discovered theme in the requested format
+func runList(args []string) int {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: json, table or tsv")
+	outputDir := flags.String("out", "", "directory checked for converted themes (defaults to Warp's themes directory)")
+	source := flags.String("source", "", "only list themes from this editor (vscode, insiders, vscodium, cursor, windsurf or builtin)")
+	vsixPath := flags.String("vsix", "", "list the themes of a .vsix package instead of installed extensions")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	switch *format {
+	case "json", "table", "tsv":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected json, table or tsv)\n", *format)
+		return exitUsage
+	}
+
+	if *source != "" && !isEditorSource(*source) {
+		fmt.Fprintf(os.Stderr, "Error: unknown source %q\n", *source)
+		return exitUsage
+	}
+
+	themesDir, err := resolveThemesDir(*outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	themes, cleanup, err := loadThemes(*vsixPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	defer cleanup()
+
+	// Without a readable manifest no theme counts as converted, which should not stop the listing
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		manifest = &Manifest{}
+	}
+
+	themes = matchThemes(themes, ThemeFilter{Source: *source})
+	listings := make([]themeListing, len(themes))
+	for i, theme := range themes {
+		listings[i] = newThemeListing(theme, themesDir, manifest)
+	}
+
+	if err := writeThemeListings(os.Stdout, listings, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// newThemeListing builds the listing for a theme, checking themesDir and its manifest for an
+// existing conversion
+func newThemeListing(theme ThemeInfo, themesDir string, manifest *Manifest) themeListing {
+	listing := themeListing{
+		Name:        theme.Label,
+		DisplayName: theme.DisplayName,
+		Type:        theme.Type,
+		Path:        theme.Path,
+		Source:      theme.Source,
+	}
+	if theme.Origin != "" {
+		listing.Path = theme.Origin
+	}
+	if theme.ExtensionMetadata != nil {
+		listing.ExtensionID = theme.ExtensionMetadata.ID()
+		listing.ExtensionVersion = theme.ExtensionMetadata.Version
+	}
+	listing.Converted = convertedThemePath(theme, themesDir, manifest) != ""
+	return listing
+}
+
+// writeThemeListings renders listings as json, an aligned table with a header, or header-less tsv
+func writeThemeListings(w io.Writer, listings []themeListing, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listings)
+	case "tsv":
+		for _, listing := range listings {
+			if _, err := fmt.Fprintln(w, strings.Join(listing.fields(), "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tDISPLAY NAME\tTYPE\tPATH\tSOURCE\tEXTENSION\tVERSION\tCONVERTED")
+		for _, listing := range listings {
+			fmt.Fprintln(tw, strings.Join(listing.fields(), "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// fields returns the listing's values in column order
+func (l themeListing) fields() []string {
+	converted := "no"
+	if l.Converted {
+		converted = "yes"
+	}
+	return []string{l.Name, l.DisplayName, l.Type, l.Path, l.Source, l.ExtensionID, l.ExtensionVersion, converted}
+}
+
+// runRestore lists the backups of replaced themes, or puts one back in place
+func runRestore(args []string) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	themeQuery := flags.String("theme", "", "theme file, e.g. one_dark_pro.yaml, or theme name to restore")
+	backupID := flags.String("backup", "", "timestamp of the backup to restore, as shown by --list (defaults to the latest)")
+	listOnly := flags.Bool("list", false, "list backups, of --theme only when given, instead of restoring")
+	outputDir := flags.String("out", "", "themes directory (defaults to Warp's themes directory)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if !*listOnly && *themeQuery == "" {
+		fmt.Fprintln(os.Stderr, "Error: --theme or --list is required")
+		flags.Usage()
+		return exitUsage
+	}
+
+	themesDir, err := resolveThemesDir(*outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	filename := ""
+	if *themeQuery != "" {
+		filename = themeFileFromQuery(*themeQuery)
+	}
+	backups, err := listBackups(themesDir, filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	if *listOnly {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "THEME FILE\tBACKUP\tREPLACED AT")
+		for _, backup := range backups {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", backup.Filename, backup.Time.Format(backupTimeFormat), backup.Time.Format("2006-01-02 15:04:05"))
+		}
+		tw.Flush()
+		return exitOK
+	}
+
+	backup, err := selectBackup(backups, *backupID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", filename, err)
+		return exitFailure
+	}
+
+	path, replaced, err := restoreBackup(themesDir, backup)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("Restored %s from the backup of %s\n", path, backup.Time.Format("2006-01-02 15:04:05"))
+	if replaced != "" {
+		fmt.Printf("  replaced version backed up to %s\n", replaced)
+	}
+
+	// Backups only hold theme files, so an image removed by uninstall stays gone
+	if manifest, err := loadManifest(themesDir); err == nil {
+		if entry, ok := manifest.entry(backup.Filename); ok && entry.Image != "" {
+			if _, err := os.Stat(filepath.Join(themesDir, entry.Image)); os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Warning: background image %s no longer exists, convert the theme again with --background-image to bring it back\n", entry.Image)
+			}
+		}
+	}
+	return exitOK
+}
+
+// themeFileFromQuery returns the file a restore refers to: a .yaml theme file or background image
+// name as given, otherwise the file a theme of that name is saved as
+func themeFileFromQuery(query string) string {
+	ext := strings.ToLower(filepath.Ext(query))
+	if ext == ".yaml" {
+		return filepath.Base(query)
+	}
+	for _, extensions := range backgroundImageFormats {
+		for _, imageExt := range extensions {
+			if ext == imageExt {
+				return filepath.Base(query)
+			}
+		}
+	}
+	return warpThemeFilename(query)
+}
+
+// selectBackup picks the backup whose timestamp starts with id, or the latest when id is empty
+func selectBackup(backups []themeBackup, id string) (themeBackup, error) {
+	if len(backups) == 0 {
+		return themeBackup{}, fmt.Errorf("no backups found")
+	}
+	if id == "" {
+		return backups[0], nil
+	}
+
+	var matches []themeBackup
+	for _, backup := range backups {
+		if strings.HasPrefix(backup.Time.Format(backupTimeFormat), id) {
+			matches = append(matches, backup)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return themeBackup{}, fmt.Errorf("no backup matching %q", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return themeBackup{}, fmt.Errorf("%q matches %d backups, give more of the timestamp", id, len(matches))
+	}
+}
+
+// installedListing is how the installed command reports a generated theme
+type installedListing struct {
+	ManifestEntry
+	Status string `json:"status"`
+}
+
+// runInstalled lists the themes this tool generated in the themes directory
+func runInstalled(args []string) int {
+	flags := flag.NewFlagSet("installed", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: json or table")
+	outputDir := flags.String("out", "", "themes directory (defaults to Warp's themes directory)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if *format != "json" && *format != "table" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected json or table)\n", *format)
+		return exitUsage
+	}
+
+	themesDir, err := resolveThemesDir(*outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	listings := make([]installedListing, len(manifest.Themes))
+	for i, entry := range manifest.Themes {
+		listings[i] = installedListing{ManifestEntry: entry, Status: entry.status(themesDir)}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(listings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tNAME\tEXTENSION\tVERSION\tCONVERTED\tSTATUS")
+	for _, listing := range listings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", listing.File, listing.Name, listing.ExtensionID, listing.ExtensionVersion, listing.ConvertedAt.Local().Format("2006-01-02 15:04"), listing.Status)
+	}
+	tw.Flush()
+	return exitOK
+}
+
+// runUninstall removes generated themes, leaving hand-made ones untouched
+func runUninstall(args []string) int {
+	flags := flag.NewFlagSet("uninstall", flag.ContinueOnError)
+	all := flags.Bool("all", false, "remove every generated theme")
+	force := flags.Bool("force", false, "also remove themes edited since they were converted")
+	outputDir := flags.String("out", "", "themes directory (defaults to Warp's themes directory)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: vscode-to-warp uninstall [flags] <theme> | --all")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	query := strings.Join(flags.Args(), " ")
+	if *all == (query != "") {
+		fmt.Fprintln(os.Stderr, "Error: exactly one of a theme or --all is required")
+		flags.Usage()
+		return exitUsage
+	}
+
+	themesDir, err := resolveThemesDir(*outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	entries := manifest.Themes
+	if !*all {
+		entries = manifest.find(query)
+		switch len(entries) {
+		case 0:
+			fmt.Fprintf(os.Stderr, "Error: no generated theme matching %q found\n", query)
+			return exitFailure
+		case 1:
+		default:
+			fmt.Fprintf(os.Stderr, "Error: %q matches %d themes, use the file name to pick one:\n", query, len(entries))
+			for _, entry := range entries {
+				fmt.Fprintf(os.Stderr, "  %s\n", entry.File)
+			}
+			return exitFailure
+		}
+	}
+	// Removing entries while ranging over the manifest's own slice would skip some
+	entries = append([]ManifestEntry(nil), entries...)
+
+	failed := 0
+	for _, entry := range entries {
+		if err := uninstallTheme(themesDir, manifest, entry, *force); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("✓ removed %s\n", entry.File)
+	}
+
+	if err := manifest.save(themesDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	if failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rgbaColor is an sRGB color with 8-bit channels and an alpha between 0 and 1
+type rgbaColor struct {
+	R, G, B uint8
+	A       float64
+}
+
+// parseColor parses a VS Code color value: #rgb, #rgba, #rrggbb or #rrggbbaa,
+// in any letter case and with surrounding whitespace
+func parseColor(value string) (rgbaColor, error) {
+	color := strings.TrimSpace(value)
+	if !strings.HasPrefix(color, "#") {
+		return rgbaColor{}, fmt.Errorf("invalid color %q: expected #rgb, #rgba, #rrggbb or #rrggbbaa", value)
+	}
+	hex := strings.ToLower(color[1:])
+
+	// Expand the short forms, #abc is #aabbcc and #abcd is #aabbccdd
+	if len(hex) == 3 || len(hex) == 4 {
+		expanded := make([]byte, 0, len(hex)*2)
+		for i := 0; i < len(hex); i++ {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	}
+	if len(hex) != 6 && len(hex) != 8 {
+		return rgbaColor{}, fmt.Errorf("invalid color %q: expected #rgb, #rgba, #rrggbb or #rrggbbaa", value)
+	}
+
+	channels, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgbaColor{}, fmt.Errorf("invalid color %q: not a hexadecimal value", value)
+	}
+
+	if len(hex) == 6 {
+		return rgbaColor{R: uint8(channels >> 16), G: uint8(channels >> 8), B: uint8(channels), A: 1}, nil
+	}
+	return rgbaColor{
+		R: uint8(channels >> 24),
+		G: uint8(channels >> 16),
+		B: uint8(channels >> 8),
+		A: float64(uint8(channels)) / 255,
+	}, nil
+}
+
+// hex formats the color as #rrggbb, dropping alpha
+func (c rgbaColor) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// over composites the color on top of an opaque background, the way VS Code renders it
+func (c rgbaColor) over(background rgbaColor) rgbaColor {
+	blend := func(fg, bg uint8) uint8 {
+		return uint8(math.Round(float64(fg)*c.A + float64(bg)*(1-c.A)))
+	}
+	return rgbaColor{
+		R: blend(c.R, background.R),
+		G: blend(c.G, background.G),
+		B: blend(c.B, background.B),
+		A: 1,
+	}
+}
+
+// hsl returns the color's hue in degrees and its saturation and lightness between 0 and 1
+func (c rgbaColor) hsl() (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+
+	delta := max - min
+	if delta == 0 {
+		// Greys have no hue
+		return 0, 0, l
+	}
+
+	s = delta / (1 - math.Abs(2*l-1))
+	switch max {
+	case r:
+		h = math.Mod((g-b)/delta, 6)
+	case g:
+		h = (b-r)/delta + 2
+	default:
+		h = (r-g)/delta + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// hueDistance returns the angle between two hues in degrees, between 0 and 180
+func hueDistance(a, b float64) float64 {
+	d := math.Abs(a - b)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// oklch is a color in the OKLCH space: perceptual lightness (0-1), chroma and hue in degrees
+type oklch struct {
+	L, C, H float64
+}
+
+// srgbToLinear converts an sRGB channel between 0 and 1 to linear light
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear light channel to sRGB between 0 and 1
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// oklch converts the color to OKLCH, ignoring alpha
+func (c rgbaColor) oklch() oklch {
+	r := srgbToLinear(float64(c.R) / 255)
+	g := srgbToLinear(float64(c.G) / 255)
+	b := srgbToLinear(float64(c.B) / 255)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	okL := 0.2104542553*l + 0.7936177850*m - 0.0040720468*s
+	okA := 1.9779984951*l - 2.4285922050*m + 0.4505937099*s
+	okB := 0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+
+	hue := math.Atan2(okB, okA) * 180 / math.Pi
+	if hue < 0 {
+		hue += 360
+	}
+	return oklch{L: okL, C: math.Hypot(okA, okB), H: hue}
+}
+
+// linearRGB converts the color to linear sRGB channels, which may fall outside 0-1 when out of gamut
+func (c oklch) linearRGB() (r, g, b float64) {
+	okA := c.C * math.Cos(c.H*math.Pi/180)
+	okB := c.C * math.Sin(c.H*math.Pi/180)
+
+	l := c.L + 0.3963377774*okA + 0.2158037573*okB
+	m := c.L - 0.1055613458*okA - 0.0638541728*okB
+	s := c.L - 0.0894841775*okA - 1.2914855480*okB
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	r = 4.0767416621*l - 3.3077115913*m + 0.2309699292*s
+	g = -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
+	b = -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+	return r, g, b
+}
+
+// rgba converts the color back to sRGB, reducing chroma until it fits the sRGB gamut
+func (c oklch) rgba() rgbaColor {
+	c.L = math.Max(0, math.Min(1, c.L))
+
+	inGamut := func(v float64) bool { return v >= -0.0001 && v <= 1.0001 }
+	r, g, b := c.linearRGB()
+	for i := 0; i < 30 && !(inGamut(r) && inGamut(g) && inGamut(b)); i++ {
+		c.C *= 0.9
+		r, g, b = c.linearRGB()
+	}
+
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, linearToSRGB(v))) * 255))
+	}
+	return rgbaColor{R: channel(r), G: channel(g), B: channel(b), A: 1}
+}
+
+// midLuminance is the relative luminance at which black and white text have the same contrast
+const midLuminance = 0.179
+
+// isDark reports whether the color reads as a dark surface, i.e. light text contrasts better on it
+func (c rgbaColor) isDark() bool {
+	return c.relativeLuminance() < midLuminance
+}
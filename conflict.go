@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policies for saving a theme whose file, or background image file, belongs to a different theme
+const (
+	conflictSkip      = "skip"      // Leave the existing file alone and do not save
+	conflictOverwrite = "overwrite" // Replace the existing file
+	conflictRename    = "rename"    // Save under a name disambiguated by the extension id
+	conflictPrompt    = "prompt"    // Ask which of the above to do
+)
+
+// maxRenameAttempts bounds the numbered names tried once the extension id suffix is taken too
+const maxRenameAttempts = 100
+
+// parseConflictPolicy validates an --on-conflict value
+func parseConflictPolicy(value string) (string, error) {
+	switch value {
+	case conflictSkip, conflictOverwrite, conflictRename, conflictPrompt:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid conflict policy %q: expected skip, overwrite, rename or prompt", value)
+}
+
+// themeIdentity tells the theme being saved apart from the other themes of a themes directory
+type themeIdentity struct {
+	Source  string // As returned by themeSource, matched against the manifest
+	BasedOn string // Attribution, matched against files converted before the manifest recorded them
+}
+
+// newThemeIdentity returns the identity of a discovered theme
+func newThemeIdentity(themeInfo ThemeInfo) themeIdentity {
+	return themeIdentity{
+		Source:  themeSource(themeInfo),
+		BasedOn: FormatBasedOnAttribution(themeInfo.Label, themeInfo.ExtensionMetadata),
+	}
+}
+
+// themeFileConflict reports whether the file at path holds a theme other than the one identified
+// by id: a hand-made theme or one converted from a different VS Code theme. Files recorded in
+// manifest are compared by source; others, such as those converted before the manifest existed,
+// by their based_on attribution. A missing file is no conflict, and neither is an earlier
+// conversion of the same theme.
+func themeFileConflict(path string, id themeIdentity, manifest *Manifest) bool {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return false
+	}
+	if entry, ok := manifest.entry(filepath.Base(path)); ok {
+		return entry.source() != id.Source
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	var existing struct {
+		BasedOn string `yaml:"based_on"`
+	}
+	if yaml.Unmarshal(data, &existing) != nil {
+		return true
+	}
+	return existing.BasedOn != id.BasedOn
+}
+
+// themeFileNames returns the names a theme is saved under, in the order they are tried:
+// its label, the label with the extension id, then numbered variants of either
+func themeFileNames(label, extensionID string) []string {
+	base := label
+	names := []string{label}
+	if extensionID != "" {
+		base = label + " " + extensionID
+		names = append(names, base)
+	}
+	for i := 2; i <= maxRenameAttempts; i++ {
+		names = append(names, fmt.Sprintf("%s %d", base, i))
+	}
+	return names
+}
+
+// imageFileConflict reports whether the file at path, where the background image at source is to
+// be copied for the theme file themeFile, is anything but that theme's own earlier copy
+func imageFileConflict(path, source, themeFile string, id themeIdentity, manifest *Manifest) bool {
+	if _, err := os.Lstat(path); os.IsNotExist(err) || sameFile(source, path) {
+		return false
+	}
+	entry, ok := manifest.entry(themeFile)
+	return !ok || entry.source() != id.Source || entry.Image != filepath.Base(path)
+}
+
+// chooseThemeName returns the name to save a theme under in themesDir following policy, or the
+// path of the conflicting file when the theme is not to be saved: with skip, or with prompt
+// until a choice is made. A name is free when neither its theme file nor, when image is set, the
+// file the background image is copied to belongs to another theme. A file not yet in the
+// manifest that holds the same theme is adopted by saving over it.
+func chooseThemeName(label, extensionID string, id themeIdentity, image, themesDir, policy string) (name, conflict string, err error) {
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		return "", "", err
+	}
+
+	// conflictAt returns the file that keeps the theme from being saved under name, if any
+	conflictAt := func(name string) string {
+		path := filepath.Join(themesDir, warpThemeFilename(name))
+		if themeFileConflict(path, id, manifest) {
+			return path
+		}
+		if image != "" {
+			imagePath := filepath.Join(themesDir, backgroundImageFilename(name, image))
+			if imageFileConflict(imagePath, image, filepath.Base(path), id, manifest) {
+				return imagePath
+			}
+		}
+		return ""
+	}
+
+	path := conflictAt(label)
+	if path == "" {
+		return label, "", nil
+	}
+
+	switch policy {
+	case conflictOverwrite:
+		return label, "", nil
+	case conflictRename:
+		for _, candidate := range themeFileNames(label, extensionID)[1:] {
+			if conflictAt(candidate) == "" {
+				return candidate, "", nil
+			}
+		}
+		return "", "", fmt.Errorf("no free filename for %q in %s", label, themesDir)
+	default:
+		return "", path, nil
+	}
+}
+
+// convertedThemePath returns where a discovered theme was converted to in the themesDir whose
+// manifest is given, or an empty string when it was not converted there or its file is gone.
+// Files missing from the manifest are matched among the names the theme could be saved under.
+func convertedThemePath(themeInfo ThemeInfo, themesDir string, manifest *Manifest) string {
+	id := newThemeIdentity(themeInfo)
+	for _, entry := range manifest.Themes {
+		if entry.source() != id.Source {
+			continue
+		}
+		path := filepath.Join(themesDir, entry.File)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	extensionID := ""
+	if themeInfo.ExtensionMetadata != nil {
+		extensionID = themeInfo.ExtensionMetadata.ID()
+	}
+	names := themeFileNames(themeInfo.Label, extensionID)
+	firstNumbered := len(names) - (maxRenameAttempts - 1)
+	for i, name := range names {
+		path := filepath.Join(themesDir, warpThemeFilename(name))
+		if _, err := os.Stat(path); err != nil {
+			// Numbered names are taken in order, so the first free one ends the search
+			if i >= firstNumbered {
+				return ""
+			}
+			continue
+		}
+		if _, recorded := manifest.entry(filepath.Base(path)); !recorded && !themeFileConflict(path, id, manifest) {
+			return path
+		}
+	}
+	return ""
+}
+
+// promptConflictPolicy asks on out which policy to apply to a conflicting file, reading the answer
+// from in. Anything but overwrite or rename skips the theme.
+func promptConflictPolicy(in *bufio.Reader, out io.Writer, displayName, path string) string {
+	fmt.Fprintf(out, "%s already belongs to another theme. Save '%s' anyway? [o]verwrite, [r]ename, [s]kip: ", path, displayName)
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "o", "overwrite":
+		return conflictOverwrite
+	case "r", "rename":
+		return conflictRename
+	default:
+		return conflictSkip
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// contrastLevels maps WCAG conformance levels to their minimum contrast ratios
+var contrastLevels = map[string]float64{
+	"aa-large":  3,
+	"aa":        4.5,
+	"aaa-large": 4.5,
+	"aaa":       7,
+}
+
+// ContrastCheck is the contrast of one ANSI slot against the theme background
+type ContrastCheck struct {
+	Slot     string  // e.g. "normal.black"
+	Color    string  // Final color of the slot
+	Ratio    float64 // Final contrast ratio against the background
+	Original string  // Color before repair, empty when the slot was not adjusted
+}
+
+// parseContrastLevel parses a WCAG level (AA, AAA, AA-large, AAA-large) or a ratio such as 4.5
+func parseContrastLevel(value string) (float64, error) {
+	if ratio, ok := contrastLevels[strings.ToLower(value)]; ok {
+		return ratio, nil
+	}
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 1 || ratio > 21 {
+		return 0, fmt.Errorf("invalid contrast level %q: expected AA, AAA, AA-large, AAA-large or a ratio between 1 and 21", value)
+	}
+	return ratio, nil
+}
+
+// relativeLuminance returns the WCAG relative luminance of a color
+func (c rgbaColor) relativeLuminance() float64 {
+	return 0.2126*srgbToLinear(float64(c.R)/255) +
+		0.7152*srgbToLinear(float64(c.G)/255) +
+		0.0722*srgbToLinear(float64(c.B)/255)
+}
+
+// contrastRatio returns the WCAG contrast ratio between two colors, from 1 to 21
+func contrastRatio(a, b rgbaColor) float64 {
+	la, lb := a.relativeLuminance(), b.relativeLuminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// checkContrast measures every ANSI slot of theme against its background, or the first stop of a
+// gradient background. When minRatio is above 1, slots below it have their OKLCH lightness moved
+// away from the background until they reach it, or as far as lightness allows; slots still below
+// it are left for the caller to report. Slots in pinned, keyed like ContrastCheck.Slot, are only
+// measured.
+func checkContrast(theme *WarpTheme, minRatio float64, pinned map[string]bool) ([]ContrastCheck, error) {
+	background, err := parseColor(theme.Background.base())
+	if err != nil {
+		return nil, fmt.Errorf("invalid background for contrast check: %w", err)
+	}
+
+	// Lighter colors stand out on dark backgrounds and darker ones on light backgrounds
+	step := 0.01
+	if !background.isDark() {
+		step = -step
+	}
+
+	palettes := []struct {
+		name    string
+		palette *ColorPalette
+	}{
+		{"normal", &theme.TerminalColors.Normal},
+		{"bright", &theme.TerminalColors.Bright},
+	}
+
+	var checks []ContrastCheck
+	for _, p := range palettes {
+		for i, slot := range p.palette.slots() {
+			color, err := parseColor(*slot)
+			if err != nil {
+				continue
+			}
+
+			check := ContrastCheck{
+				Slot:  p.name + "." + strings.ToLower(ansiColorNames[i]),
+				Color: *slot,
+				Ratio: contrastRatio(color, background),
+			}
+
+			if minRatio > 1 && check.Ratio < minRatio && !pinned[check.Slot] {
+				// Only the bound lightness is moving towards ends the search, so black still
+				// lightens on a black background
+				adjusted := color.oklch()
+				for check.Ratio < minRatio && ((step > 0 && adjusted.L < 1) || (step < 0 && adjusted.L > 0)) {
+					adjusted.L = math.Max(0, math.Min(1, adjusted.L+step))
+					color = adjusted.rgba()
+					check.Ratio = contrastRatio(color, background)
+				}
+				if hex := color.hex(); hex != *slot {
+					check.Original = *slot
+					check.Color = hex
+					*slot = hex
+				}
+			}
+
+			checks = append(checks, check)
+		}
+	}
+
+	return checks, nil
+}
+
+// slots returns pointers to the palette's colors in ANSI order
+func (p *ColorPalette) slots() []*string {
+	return []*string{&p.Black, &p.Red, &p.Green, &p.Yellow, &p.Blue, &p.Magenta, &p.Cyan, &p.White}
+}
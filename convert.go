@@ -0,0 +1,262 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConvertOptions controls how a discovered theme is converted and saved
+type ConvertOptions struct {
+	OutputDir  string // Directory to write the theme to; defaults to Warp's themes directory
+	DeriveANSI bool   // Synthesize missing ANSI colors from the theme's own colors instead of using defaults
+
+	// VS Code color keys tried in order for the background and foreground; defaults when empty
+	BackgroundKeys []string
+	ForegroundKeys []string
+
+	Gradient    string  // Derive a vertical or horizontal background gradient; solid when empty
+	MinContrast float64 // ANSI colors below this contrast ratio against the background are repaired; 0 disables
+
+	OnConflict   string // What to do when a theme's file belongs to another theme: skip, overwrite, rename or prompt
+	NameTemplate string // Display name of converted themes, e.g. "{theme} (VS Code)"; the label when empty
+
+	BackgroundImage string // Image shown behind the terminal, copied next to the theme; none when empty
+	ImageOpacity    int    // Opacity of the background image, 0 to 100
+
+	Mapping   *MappingProfile // Sources of every Warp color; the default mapping when nil
+	Overrides ThemeOverrides  // Values patched into matching themes after conversion
+}
+
+// registerConversionFlags adds the flags shared by every command that converts themes
+func registerConversionFlags(flags *flag.FlagSet, opts *ConvertOptions) {
+	flags.BoolVar(&opts.DeriveANSI, "derive-ansi", false, "derive missing ANSI colors from the theme's syntax and diagnostic colors")
+	flags.Func("background-keys", "comma-separated VS Code keys tried in order for the background (default "+strings.Join(defaultBackgroundKeys, ",")+")", func(value string) error {
+		opts.BackgroundKeys = splitKeyList(value)
+		return nil
+	})
+	flags.Func("foreground-keys", "comma-separated VS Code keys tried in order for the foreground (default "+strings.Join(defaultForegroundKeys, ",")+")", func(value string) error {
+		opts.ForegroundKeys = splitKeyList(value)
+		return nil
+	})
+	flags.Func("on-conflict", "when a theme's file belongs to another theme: skip, overwrite, rename or prompt (default rename, prompt in the interactive UI)", func(value string) error {
+		policy, err := parseConflictPolicy(value)
+		opts.OnConflict = policy
+		return err
+	})
+	flags.StringVar(&opts.NameTemplate, "name-template", defaultNameTemplate, "display name of converted themes; {theme} is the theme label, {extension} the extension name")
+	flags.Func("background-image", "JPEG or PNG image to show behind the terminal", func(value string) error {
+		opts.BackgroundImage = value
+		return validateBackgroundImage(value)
+	})
+	opts.ImageOpacity = 100
+	flags.Func("image-opacity", "opacity of the background image from 0 to 100 (default 100)", func(value string) error {
+		opacity, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid image opacity %q: expected 0 to 100", value)
+		}
+		opts.ImageOpacity = opacity
+		return parseImageOpacity(opacity)
+	})
+	flags.Func("mapping", "YAML or JSON profile mapping VS Code keys, token scopes and literal colors to Warp colors", func(value string) error {
+		profile, err := LoadMappingProfile(value)
+		opts.Mapping = profile
+		return err
+	})
+	flags.Func("overrides", "YAML or JSON file of Warp values to apply to themes by label, display name or id", func(value string) error {
+		overrides, err := LoadThemeOverrides(value)
+		opts.Overrides = overrides
+		return err
+	})
+	flags.Func("gradient", "fade the background into the sidebar or title bar color (vertical or horizontal)", func(value string) error {
+		direction, err := parseGradientDirection(value)
+		opts.Gradient = direction
+		return err
+	})
+	flags.Func("ensure-contrast", "repair ANSI colors below a WCAG contrast level against the background (AA, AAA, AA-large, AAA-large or a ratio)", func(value string) error {
+		ratio, err := parseContrastLevel(value)
+		opts.MinContrast = ratio
+		return err
+	})
+}
+
+// splitKeyList splits a comma-separated list of color keys, ignoring blanks
+func splitKeyList(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ConversionResult records the outcome of converting one theme
+type ConversionResult struct {
+	Theme  ThemeInfo
+	Path   string            // Written theme path, empty on failure
+	Backup string            // Backup of the file the theme replaced, empty when nothing was replaced
+	Report *ConversionReport // Nil when the theme failed to load or convert
+	Err    error
+
+	// Path of the file belonging to another theme when the theme was not saved because of it:
+	// skipped by policy, or waiting for a choice under the prompt policy
+	Conflict string
+}
+
+// warnings returns the problems that did not stop the conversion
+func (r ConversionResult) warnings() []string {
+	if r.Report == nil {
+		return nil
+	}
+	return r.Report.Warnings
+}
+
+// convertThemeInfo loads, converts and saves a single discovered theme
+func convertThemeInfo(themeInfo ThemeInfo, opts ConvertOptions) ConversionResult {
+	result := ConversionResult{Theme: themeInfo}
+
+	// Load the VS Code theme
+	vscodeTheme, err := LoadVSCodeTheme(themeInfo.Path)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to load theme: %w", err)
+		return result
+	}
+
+	// Themes contributed through package.json may leave the name to their label
+	// and the type to their uiTheme
+	if themeInfo.Label != "" {
+		vscodeTheme.Name = themeInfo.Label
+	}
+	if themeInfo.Type != "" {
+		vscodeTheme.Type = themeInfo.Type
+	}
+
+	// Convert to Warp theme, using extension metadata for attribution when available
+	warpTheme, report, err := ConvertVSCodeToWarp(vscodeTheme, themeInfo.ExtensionMetadata, opts)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to convert theme: %w", err)
+		return result
+	}
+	result.Report = report
+
+	// Reapply the user's own tweaks so they survive reconverting the theme
+	report.Overrides = opts.Overrides.apply(warpTheme, themeInfo)
+
+	// Analyze, and when requested repair, the contrast of the final palette, leaving the colors
+	// pinned by overrides alone
+	pinned := opts.Overrides.pinnedSlots(themeInfo)
+	report.Contrast, err = checkContrast(warpTheme, opts.MinContrast, pinned)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to check contrast: %w", err)
+		return result
+	}
+	for _, check := range report.Contrast {
+		switch {
+		case check.Ratio >= opts.MinContrast:
+		case pinned[check.Slot]:
+			report.warnf("%s: overridden color %s is below the contrast target (%.2f:1), keeping it", check.Slot, check.Color, check.Ratio)
+		default:
+			report.warnf("%s: %s cannot reach the contrast target, best is %.2f:1", check.Slot, check.Color, check.Ratio)
+		}
+	}
+
+	if opts.BackgroundImage != "" {
+		warpTheme.BackgroundImage = &BackgroundImage{Path: opts.BackgroundImage, Opacity: opts.ImageOpacity}
+	}
+
+	// Save the Warp theme, named after its label whatever name it displays, unless that would
+	// replace a different theme
+	themesDir, err := resolveThemesDir(opts.OutputDir)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	extensionID := ""
+	if themeInfo.ExtensionMetadata != nil {
+		extensionID = themeInfo.ExtensionMetadata.ID()
+	}
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = conflictRename
+	}
+	name, conflict, err := chooseThemeName(vscodeTheme.Name, extensionID, newThemeIdentity(themeInfo), opts.BackgroundImage, themesDir, policy)
+	if err != nil || conflict != "" {
+		result.Conflict, result.Err = conflict, err
+		return result
+	}
+	result.Path, result.Backup, err = SaveWarpTheme(warpTheme, name, themesDir)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to save theme: %w", err)
+		return result
+	}
+
+	// Remember the file came from here, so it can be told apart from hand-made themes
+	image := ""
+	if warpTheme.BackgroundImage != nil {
+		image = backgroundImageFilename(name, warpTheme.BackgroundImage.Path)
+	}
+	if err := recordConversion(result.Path, image, themeInfo, warpTheme); err != nil {
+		report.warnf("%v", err)
+	}
+
+	return result
+}
+
+// convertThemes converts every theme in turn, carrying on past individual failures
+func convertThemes(themes []ThemeInfo, opts ConvertOptions) []ConversionResult {
+	results := make([]ConversionResult, len(themes))
+	for i, themeInfo := range themes {
+		results[i] = convertThemeInfo(themeInfo, opts)
+	}
+	return results
+}
+
+// countSkipped returns how many results in a batch were not saved because of a conflict
+func countSkipped(results []ConversionResult) int {
+	skipped := 0
+	for _, result := range results {
+		if result.Err == nil && result.Conflict != "" {
+			skipped++
+		}
+	}
+	return skipped
+}
+
+// countFailures returns how many results in a batch failed
+func countFailures(results []ConversionResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// ThemeFilter selects themes by display name, type and editor source; empty fields match everything
+type ThemeFilter struct {
+	Text   string // Case-insensitive substring of the display name
+	Type   string // "dark" or "light"
+	Source string // Editor source ID
+}
+
+// matchThemes returns the themes selected by filter
+func matchThemes(themes []ThemeInfo, filter ThemeFilter) []ThemeInfo {
+	matches := make([]ThemeInfo, 0)
+	textLower := strings.ToLower(filter.Text)
+	for _, theme := range themes {
+		if filter.Type != "" && !strings.EqualFold(theme.Type, filter.Type) {
+			continue
+		}
+		if filter.Source != "" && theme.Source != filter.Source {
+			continue
+		}
+		if filter.Text != "" && !strings.Contains(strings.ToLower(theme.DisplayName), textLower) {
+			continue
+		}
+		matches = append(matches, theme)
+	}
+	return matches
+}
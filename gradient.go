@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WarpColor is a Warp background or accent: a solid color, or a gradient from top to bottom
+// or left to right
+type WarpColor struct {
+	Color    string        // Solid color, empty for gradients
+	Gradient *WarpGradient // Gradient, nil for solid colors
+}
+
+// WarpGradient is a two-stop gradient, either vertical (top, bottom) or horizontal (left, right)
+type WarpGradient struct {
+	Top    string `yaml:"top,omitempty"`
+	Bottom string `yaml:"bottom,omitempty"`
+	Left   string `yaml:"left,omitempty"`
+	Right  string `yaml:"right,omitempty"`
+}
+
+// Gradient directions accepted by --gradient
+const (
+	gradientVertical   = "vertical"
+	gradientHorizontal = "horizontal"
+)
+
+// gradientSurfaceKeys are the VS Code surfaces around the editor, tried in order for the second
+// stop of a derived background gradient
+var gradientSurfaceKeys = []string{"sideBar.background", "titleBar.activeBackground", "activityBar.background"}
+
+// solidColor returns a solid WarpColor
+func solidColor(color string) WarpColor {
+	return WarpColor{Color: color}
+}
+
+// newGradient returns a gradient from start to end in direction, vertical or horizontal
+func newGradient(direction, start, end string) WarpColor {
+	if direction == gradientHorizontal {
+		return WarpColor{Gradient: &WarpGradient{Left: start, Right: end}}
+	}
+	return WarpColor{Gradient: &WarpGradient{Top: start, Bottom: end}}
+}
+
+// base returns the solid color, or the first stop of a gradient. Colors are blended over and
+// contrast is measured against the base of the background.
+func (c WarpColor) base() string {
+	if c.Gradient == nil {
+		return c.Color
+	}
+	if c.Gradient.Top != "" {
+		return c.Gradient.Top
+	}
+	return c.Gradient.Left
+}
+
+// IsZero reports whether no color is set, so omitempty leaves it out
+func (c WarpColor) IsZero() bool {
+	return c.Color == "" && c.Gradient == nil
+}
+
+// MarshalYAML writes solid colors as a plain string and gradients as a mapping of their stops
+func (c WarpColor) MarshalYAML() (interface{}, error) {
+	if c.Gradient != nil {
+		return c.Gradient, nil
+	}
+	return c.Color, nil
+}
+
+// UnmarshalYAML reads a plain color string or a gradient mapping
+func (c *WarpColor) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*c = WarpColor{}
+		return value.Decode(&c.Color)
+	}
+	gradient := &WarpGradient{}
+	if err := value.Decode(gradient); err != nil {
+		return err
+	}
+	*c = WarpColor{Gradient: gradient}
+	return nil
+}
+
+// validate checks that the color parses and that a gradient has exactly one pair of stops
+func (c WarpColor) validate() error {
+	if c.Gradient == nil {
+		if c.Color == "" {
+			return nil
+		}
+		_, err := parseColor(c.Color)
+		return err
+	}
+
+	g := c.Gradient
+	vertical := g.Top != "" && g.Bottom != "" && g.Left == "" && g.Right == ""
+	horizontal := g.Left != "" && g.Right != "" && g.Top == "" && g.Bottom == ""
+	if !vertical && !horizontal {
+		return fmt.Errorf("a gradient needs either top and bottom or left and right")
+	}
+	for _, stop := range []string{g.Top, g.Bottom, g.Left, g.Right} {
+		if stop == "" {
+			continue
+		}
+		if _, err := parseColor(stop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clean normalizes every stop to an opaque #rrggbb color composited over background
+func (c WarpColor) clean(background string) (WarpColor, error) {
+	if c.Gradient == nil {
+		color, err := cleanColor(c.Color, background)
+		return solidColor(color), err
+	}
+
+	gradient := *c.Gradient
+	for _, stop := range []*string{&gradient.Top, &gradient.Bottom, &gradient.Left, &gradient.Right} {
+		if *stop == "" {
+			continue
+		}
+		cleaned, err := cleanColor(*stop, background)
+		if err != nil {
+			return WarpColor{}, err
+		}
+		*stop = cleaned
+	}
+	return WarpColor{Gradient: &gradient}, nil
+}
+
+// parseGradientDirection validates a --gradient value
+func parseGradientDirection(value string) (string, error) {
+	if value != gradientVertical && value != gradientHorizontal {
+		return "", fmt.Errorf("invalid gradient %q: expected vertical or horizontal", value)
+	}
+	return value, nil
+}
+
+// deriveBackgroundGradient returns a gradient from background to the first surrounding VS Code
+// surface that differs from it, and false when the theme paints every surface the same
+func deriveBackgroundGradient(colors map[string]string, background, direction string) (WarpColor, bool) {
+	for _, key := range gradientSurfaceKeys {
+		color, exists := colors[key]
+		if !exists || color == "" {
+			continue
+		}
+		surface, err := cleanColor(color, background)
+		if err != nil || surface == background {
+			continue
+		}
+		return newGradient(direction, background, surface), true
+	}
+	return WarpColor{}, false
+}
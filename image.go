@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BackgroundImage is the image Warp draws behind the terminal. Path is relative to the theme file
+// once saved; before that it points at the image to copy.
+type BackgroundImage struct {
+	Path    string `yaml:"path"`
+	Opacity int    `yaml:"opacity"` // 0 to 100
+}
+
+// backgroundImageFormats maps the image formats Warp displays to their file extensions
+var backgroundImageFormats = map[string][]string{
+	"jpeg": {".jpg", ".jpeg"},
+	"png":  {".png"},
+}
+
+// validateBackgroundImage checks that path is a readable JPEG or PNG image with a matching extension
+func validateBackgroundImage(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open background image: %w", err)
+	}
+	defer file.Close()
+
+	_, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return fmt.Errorf("unsupported background image %s: expected a JPEG or PNG image", path)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, allowed := range backgroundImageFormats[format] {
+		if ext == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("background image %s is a %s image, its extension should be one of %s", path, format, strings.Join(backgroundImageFormats[format], ", "))
+}
+
+// parseImageOpacity validates an --image-opacity value
+func parseImageOpacity(opacity int) error {
+	if opacity < 0 || opacity > 100 {
+		return fmt.Errorf("invalid image opacity %d: expected 0 to 100", opacity)
+	}
+	return nil
+}
+
+// copyBackgroundImage copies the image at source into themesDir as <theme filename>.<ext> and
+// returns its path relative to themesDir. A different file already there is backed up first.
+func copyBackgroundImage(source, themesDir, name string) (string, error) {
+	filename := backgroundImageFilename(name, source)
+	target := filepath.Join(themesDir, filename)
+
+	// Reconverting a theme whose image is already in place needs no copy
+	if sameFile(source, target) {
+		return filename, nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+	if _, err := backupThemeFile(target, data); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", target, err)
+	}
+	return filename, writeFileAtomic(target, data, 0644)
+}
+
+// backgroundImageFilename returns the name the image at source is copied to for a theme
+func backgroundImageFilename(name, source string) string {
+	return cleanFilename(name) + strings.ToLower(filepath.Ext(source))
+}
+
+// sameFile reports whether both paths exist and name the same file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
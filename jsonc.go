@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// unmarshalJSONC parses JSON with comments, as used by VS Code for theme files and manifests
+func unmarshalJSONC(data []byte, v interface{}) error {
+	return json.Unmarshal(stripJSONC(data), v)
+}
+
+// loadConfigFile decodes the YAML or JSON file at path into v, rejecting unknown fields. what
+// names the file in errors, e.g. "mapping profile". An empty file leaves v untouched.
+func loadConfigFile(path, what string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		// JSON is YAML once comments and trailing commas are gone
+		data = stripJSONC(data)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse %s %s: %w", what, path, err)
+	}
+	return nil
+}
+
+// stripJSONC turns JSON with comments into plain JSON by removing a leading byte order mark,
+// line and block comments, and trailing commas before a closing bracket or brace.
+// String contents are left untouched.
+func stripJSONC(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	result := make([]byte, 0, len(data))
+	// Index in result of a comma that may turn out to be trailing, or -1
+	pendingComma := -1
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			result = append(result, c)
+			if c == '\\' && i+1 < len(data) {
+				// Keep escaped characters, including escaped quotes
+				i++
+				result = append(result, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			// Line comment, keep the newline so error offsets stay meaningful
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			// Block comment
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ',':
+			pendingComma = len(result)
+			result = append(result, c)
+		case c == '}' || c == ']':
+			if pendingComma != -1 {
+				// Only whitespace separates the comma from the closing bracket
+				result = append(result[:pendingComma], result[pendingComma+1:]...)
+			}
+			pendingComma = -1
+			result = append(result, c)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			result = append(result, c)
+		default:
+			if c == '"' {
+				inString = true
+			}
+			pendingComma = -1
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -24,6 +25,7 @@ var (
 
 // Model represents the application state
 type Model struct {
+	opts         ConvertOptions
 	list         list.Model
 	textInput    textinput.Model
 	themes       []ThemeInfo
@@ -35,6 +37,12 @@ type Model struct {
 	errorMsg     string
 	filterMode   bool
 	filterText   string
+	savedPath    string
+	backupPath   string
+	results      []ConversionResult
+	sourceFilter string
+	report       *ConversionReport
+	conflicts    []ConversionResult // Conversions waiting for a choice before replacing another theme's file
 }
 
 // item represents a theme item in the list
@@ -57,11 +65,14 @@ func (i item) Description() string {
 	} else if i.theme.Type == "light" {
 		typeStr = "Light theme"
 	}
+	if i.theme.SourceName != "" {
+		return fmt.Sprintf("%s • %s • %s", typeStr, i.theme.SourceName, i.theme.Path)
+	}
 	return fmt.Sprintf("%s • %s", typeStr, i.theme.Path)
 }
 
 // initialModel sets up the initial application state
-func initialModel() Model {
+func initialModel(opts ConvertOptions) Model {
 	// Check platform support
 	if err := validatePlatformSupport(); err != nil {
 		log.Fatal(err)
@@ -74,7 +85,7 @@ func initialModel() Model {
 	}
 
 	if len(themes) == 0 {
-		log.Fatal("No VS Code themes found. Please ensure you have VS Code installed with some theme extensions.")
+		log.Fatal("No VS Code themes found. Please ensure you have VS Code or a compatible editor installed with some theme extensions.")
 	}
 
 	// Create list items
@@ -98,7 +109,13 @@ func initialModel() Model {
 	ti.CharLimit = 50
 	ti.Width = 50
 
+	// Ask before replacing other themes unless told otherwise
+	if opts.OnConflict == "" {
+		opts.OnConflict = conflictPrompt
+	}
+
 	return Model{
+		opts:           opts,
 		list:           l,
 		textInput:      ti,
 		themes:         themes,
@@ -117,11 +134,23 @@ func (m Model) View() string {
 	}
 
 	if m.errorMsg != "" {
-		return fmt.Sprintf("\n  ❌ Error: %s\n\n  Press 'q' to quit.\n", m.errorMsg)
+		return fmt.Sprintf("\n  ❌ Error: %s\n\n  Press Esc to return to the list or 'q' to quit.\n", m.errorMsg)
+	}
+
+	if len(m.conflicts) > 0 {
+		return m.conflictView()
+	}
+
+	if m.converted && m.results != nil {
+		return m.batchSummaryView()
 	}
 
 	if m.converted {
-		return fmt.Sprintf("\n  ✅ Successfully converted '%s' to Warp theme!\n  \n  The theme has been saved to ~/.warp/themes/\n  You can now select it in Warp's settings.\n\n  Press 'q' to quit.\n", m.choice)
+		backup := ""
+		if m.backupPath != "" {
+			backup = fmt.Sprintf("  The previous version was backed up to %s\n", m.backupPath)
+		}
+		return fmt.Sprintf("\n  ✅ Successfully converted '%s' to Warp theme!\n  \n  The theme has been saved to %s\n%s  You can now select it in Warp's settings.\n%s\n  Press Esc to return to the list or 'q' to quit.\n", m.choice, m.savedPath, backup, formatReport(m.report))
 	}
 
 	if m.converting {
@@ -132,6 +161,10 @@ func (m Model) View() string {
 	var content strings.Builder
 	content.WriteString("\n")
 	
+	if m.sourceFilter != "" {
+		content.WriteString(fmt.Sprintf("🗂  Source: %s • Press s to change\n", m.sourceFilterName()))
+	}
+
 	if m.filterMode {
 		// Show filter input when in filter mode
 		content.WriteString("🔍 Filter: ")
@@ -143,7 +176,7 @@ func (m Model) View() string {
 		if m.filterText != "" {
 			content.WriteString(fmt.Sprintf("🔍 Filtered by: \"%s\" (%d results) • Press / to change filter\n\n", m.filterText, len(m.filteredThemes)))
 		} else {
-			content.WriteString("💡 Press / to filter • j/k or ↑/↓ to navigate • Enter to convert\n\n")
+			content.WriteString("💡 Press / to filter • j/k or ↑/↓ to navigate • Enter to convert • A to convert all\n\n")
 		}
 	}
 	
@@ -153,16 +186,10 @@ func (m Model) View() string {
 
 // filterThemes filters the theme list based on the filter text
 func (m *Model) filterThemes() {
-	if m.filterText == "" {
+	if m.filterText == "" && m.sourceFilter == "" {
 		m.filteredThemes = m.themes
 	} else {
-		m.filteredThemes = make([]ThemeInfo, 0)
-		filterLower := strings.ToLower(m.filterText)
-		for _, theme := range m.themes {
-			if strings.Contains(strings.ToLower(theme.DisplayName), filterLower) {
-				m.filteredThemes = append(m.filteredThemes, theme)
-			}
-		}
+		m.filteredThemes = matchThemes(m.themes, ThemeFilter{Text: m.filterText, Source: m.sourceFilter})
 	}
 	
 	// Update list items
@@ -176,31 +203,136 @@ func (m *Model) filterThemes() {
 // convertTheme handles the conversion process
 func (m Model) convertTheme(themeInfo ThemeInfo) tea.Cmd {
 	return func() tea.Msg {
-		// Load the VS Code theme
-		vscodeTheme, err := LoadVSCodeTheme(themeInfo.Path)
-		if err != nil {
-			return errorMsg{fmt.Sprintf("Failed to load theme: %v", err)}
+		result := convertThemeInfo(themeInfo, m.opts)
+		if result.Err != nil {
+			return errorMsg{result.Err.Error()}
+		}
+		if result.Conflict != "" {
+			if m.opts.OnConflict == conflictPrompt {
+				return conflictMsg{results: []ConversionResult{result}}
+			}
+			return errorMsg{fmt.Sprintf("skipped, %s belongs to another theme", result.Conflict)}
 		}
 
-		// Try to load extension metadata for attribution
-		var extensionMetadata *ExtensionMetadata
-		if metadata, err := LoadExtensionMetadata(themeInfo.Path); err == nil {
-			extensionMetadata = metadata
+		return convertedMsg{path: result.Path, backup: result.Backup, report: result.Report}
+	}
+}
+
+// cycleSourceFilter switches the source filter to the next editor that has themes, wrapping back to all editors
+func (m *Model) cycleSourceFilter() {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, theme := range m.themes {
+		if !seen[theme.Source] {
+			seen[theme.Source] = true
+			sources = append(sources, theme.Source)
 		}
+	}
 
-		// Convert to Warp theme
-		warpTheme, err := ConvertVSCodeToWarp(vscodeTheme, extensionMetadata)
-		if err != nil {
-			return errorMsg{fmt.Sprintf("Failed to convert theme: %v", err)}
+	next := ""
+	for i, source := range sources {
+		if source == m.sourceFilter && i+1 < len(sources) {
+			next = sources[i+1]
+			break
 		}
+	}
+	if m.sourceFilter == "" && len(sources) > 0 {
+		next = sources[0]
+	}
+
+	m.sourceFilter = next
+	m.filterThemes()
+}
+
+// sourceFilterName returns the human readable name of the current source filter
+func (m Model) sourceFilterName() string {
+	for _, theme := range m.themes {
+		if theme.Source == m.sourceFilter {
+			return theme.SourceName
+		}
+	}
+	return m.sourceFilter
+}
 
-		// Save the Warp theme
-		if err := SaveWarpTheme(warpTheme, vscodeTheme.Name); err != nil {
-			return errorMsg{fmt.Sprintf("Failed to save theme: %v", err)}
+// convertAllThemes converts every theme currently shown in the list
+func (m Model) convertAllThemes(themes []ThemeInfo) tea.Cmd {
+	return func() tea.Msg {
+		return batchConvertedMsg{results: convertThemes(themes, m.opts)}
+	}
+}
+
+// resolveConflicts converts the themes waiting on a conflict again, applying policy to them
+func (m Model) resolveConflicts(policy string) tea.Cmd {
+	conflicts := m.conflicts
+	opts := m.opts
+	opts.OnConflict = policy
+	return func() tea.Msg {
+		results := make([]ConversionResult, len(conflicts))
+		for i, conflict := range conflicts {
+			results[i] = convertThemeInfo(conflict.Theme, opts)
 		}
+		return conflictsResolvedMsg{results: results}
+	}
+}
 
-		return convertedMsg{}
+// conflictView asks what to do with themes whose files hold other themes
+func (m Model) conflictView() string {
+	var content strings.Builder
+	content.WriteString("\n  ⚠️  These files already hold other themes:\n\n")
+	for _, conflict := range m.conflicts {
+		content.WriteString(fmt.Sprintf("    • %s → %s\n", conflict.Theme.DisplayName, conflict.Conflict))
+	}
+	content.WriteString("\n  Press o to overwrite, r to save under a new name, or s to skip.\n")
+	return content.String()
+}
+
+// batchSummaryView reports the outcome of converting all listed themes
+func (m Model) batchSummaryView() string {
+	var content strings.Builder
+	content.WriteString("\n")
+	for _, result := range m.results {
+		if result.Err != nil {
+			content.WriteString(fmt.Sprintf("  ❌ %s: %v\n", result.Theme.DisplayName, result.Err))
+		} else if result.Conflict != "" {
+			content.WriteString(fmt.Sprintf("  ⏭  %s: skipped, %s belongs to another theme\n", result.Theme.DisplayName, result.Conflict))
+		} else if len(result.warnings()) > 0 {
+			content.WriteString(fmt.Sprintf("  ⚠️  %s (%d warnings)\n", result.Theme.DisplayName, len(result.warnings())))
+		} else {
+			content.WriteString(fmt.Sprintf("  ✅ %s\n", result.Theme.DisplayName))
+		}
 	}
+
+	failed, skipped := countFailures(m.results), countSkipped(m.results)
+	content.WriteString(fmt.Sprintf("\n  Converted %d of %d themes (%d skipped, %d failed).\n", len(m.results)-failed-skipped, len(m.results), skipped, failed))
+	content.WriteString("\n  Press Esc to return to the list or 'q' to quit.\n")
+	return content.String()
+}
+
+// formatReport renders where the background and foreground came from and any conversion warnings
+func formatReport(report *ConversionReport) string {
+	if report == nil {
+		return ""
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("\n  Background from %s, foreground from %s\n", report.BackgroundKey, report.ForegroundKey))
+	if len(report.Overrides) > 0 {
+		content.WriteString(fmt.Sprintf("  Overrides applied: %s\n", strings.Join(report.Overrides, ", ")))
+	}
+	for _, check := range report.Contrast {
+		if check.Original != "" {
+			content.WriteString(fmt.Sprintf("  Repaired %s for contrast: %s → %s (%.2f:1)\n", check.Slot, check.Original, check.Color, check.Ratio))
+		} else if check.Ratio < contrastLevels["aa-large"] {
+			content.WriteString(fmt.Sprintf("  Low contrast %s: %s (%.2f:1)\n", check.Slot, check.Color, check.Ratio))
+		}
+	}
+	if len(report.Warnings) > 0 {
+		content.WriteString("\n  ⚠️  Warnings:\n")
+		for _, warning := range report.Warnings {
+			content.WriteString(fmt.Sprintf("    • %s\n", warning))
+		}
+	}
+	return content.String()
 }
 
 // Message types for async operations
@@ -208,7 +340,23 @@ type errorMsg struct {
 	err string
 }
 
-type convertedMsg struct{}
+type convertedMsg struct {
+	path   string
+	backup string
+	report *ConversionReport
+}
+
+type batchConvertedMsg struct {
+	results []ConversionResult
+}
+
+type conflictMsg struct {
+	results []ConversionResult
+}
+
+type conflictsResolvedMsg struct {
+	results []ConversionResult
+}
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -220,6 +368,55 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case convertedMsg:
 		m.converting = false
 		m.converted = true
+		m.savedPath = msg.path
+		m.backupPath = msg.backup
+		m.report = msg.report
+		return m, nil
+
+	case batchConvertedMsg:
+		m.converting = false
+		m.converted = true
+		m.results = msg.results
+		if m.opts.OnConflict == conflictPrompt {
+			for _, result := range msg.results {
+				if result.Err == nil && result.Conflict != "" {
+					m.conflicts = append(m.conflicts, result)
+				}
+			}
+		}
+		return m, nil
+
+	case conflictMsg:
+		m.converting = false
+		m.conflicts = msg.results
+		return m, nil
+
+	case conflictsResolvedMsg:
+		m.converting = false
+		m.converted = true
+		m.conflicts = nil
+		if m.results != nil {
+			// Replace the batch entries of the themes that were waiting
+			for _, resolved := range msg.results {
+				for i, result := range m.results {
+					if result.Theme.Path == resolved.Theme.Path && result.Theme.DisplayName == resolved.Theme.DisplayName {
+						m.results[i] = resolved
+					}
+				}
+			}
+			return m, nil
+		}
+		result := msg.results[0]
+		switch {
+		case result.Err != nil:
+			m.errorMsg = result.Err.Error()
+		case result.Conflict != "":
+			m.errorMsg = fmt.Sprintf("skipped, %s belongs to another theme", result.Conflict)
+		default:
+			m.savedPath = result.Path
+			m.backupPath = result.Backup
+			m.report = result.Report
+		}
 		return m, nil
 
 	case tea.WindowSizeMsg:
@@ -227,9 +424,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// Waiting for a choice about replacing other themes
+		if len(m.conflicts) > 0 {
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "o", "r", "s", "esc":
+				policy := map[string]string{"o": conflictOverwrite, "r": conflictRename, "s": conflictSkip, "esc": conflictSkip}[msg.String()]
+				cmd := m.resolveConflicts(policy)
+				m.conflicts = nil
+				m.converting = true
+				return m, cmd
+			}
+			return m, nil
+		}
+
 		// Handle different states
 		if m.converting || m.converted || m.errorMsg != "" {
-			// In conversion or end states, only handle q and ctrl+c
+			// In conversion or end states, only handle q, ctrl+c and returning to the list
 			switch msg.String() {
 			case "ctrl+c":
 				m.quitting = true
@@ -237,6 +450,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "q":
 				m.quitting = true
 				return m, tea.Quit
+			case "esc":
+				if !m.converting {
+					m.converted = false
+					m.errorMsg = ""
+					m.results = nil
+				}
 			}
 			return m, nil
 		}
@@ -298,6 +517,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, m.convertTheme(i.theme)
 				}
 				return m, nil
+			case "s":
+				// Cycle through editor sources
+				m.cycleSourceFilter()
+				return m, nil
+			case "A":
+				// Convert every theme in the (filtered) list
+				if len(m.filteredThemes) > 0 {
+					m.choice = fmt.Sprintf("%d themes", len(m.filteredThemes))
+					m.converting = true
+					return m, m.convertAllThemes(m.filteredThemes)
+				}
+				return m, nil
 			case "up", "k":
 				// Vim-style up navigation
 				m.list.CursorUp()
@@ -353,6 +584,12 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	if len(os.Args) > 1 && (os.Args[1] == "--help" || os.Args[1] == "-h") {
 		os, arch := getPlatformInfo()
 		fmt.Println("VS Code to Warp Theme Converter")
@@ -364,7 +601,51 @@ func main() {
 		fmt.Println()
 		fmt.Println("✅ Warp is now supported on all platforms: Windows, macOS, and Linux!")
 		fmt.Println()
-		fmt.Println("Usage: vscode-to-warp")
+		fmt.Println("Usage: vscode-to-warp [command] [flags]")
+		fmt.Println()
+		fmt.Println("Commands:")
+		fmt.Println("  (none)      Launch the interactive theme picker")
+		fmt.Println("  convert     Convert a theme without the interactive UI")
+		fmt.Println("              --theme NAME   Theme name, display name or path to a theme file")
+		fmt.Println("              --out DIR      Output directory (defaults to Warp's themes directory)")
+		fmt.Println("              --all          Convert every discovered theme instead of one")
+		fmt.Println("              --filter TEXT  With --all, only themes whose name contains TEXT")
+		fmt.Println("              --type TYPE    With --all, only dark or light themes")
+		fmt.Println("              --source ID    Only themes from one editor, or builtin for VS Code's own")
+		fmt.Println("              --vsix FILE    Use the themes of a .vsix package without installing it")
+		fmt.Println("              --contrast-report  Print every ANSI color's contrast against the background")
+		fmt.Println("  list        List discovered themes")
+		fmt.Println("              --format FMT   Output format: table (default), json or tsv")
+		fmt.Println("              --out DIR      Directory checked for converted themes")
+		fmt.Println("              --source ID    Only themes from one editor, or builtin for VS Code's own")
+		fmt.Println("              --vsix FILE    List the themes of a .vsix package")
+		fmt.Println("  restore     Put back a theme file replaced by a conversion")
+		fmt.Println("              --theme NAME   Theme file or name to restore")
+		fmt.Println("              --backup TIME  Backup to restore, as shown by --list (default latest)")
+		fmt.Println("              --list         List backups instead of restoring")
+		fmt.Println("              --out DIR      Themes directory (defaults to Warp's themes directory)")
+		fmt.Println("  installed   List the themes this tool generated and whether they were edited since")
+		fmt.Println("              --format FMT   Output format: table (default) or json")
+		fmt.Println("              --out DIR      Themes directory (defaults to Warp's themes directory)")
+		fmt.Println("  uninstall   Remove generated themes: uninstall THEME or uninstall --all")
+		fmt.Println("              --force        Also remove themes edited since they were converted")
+		fmt.Println("              --out DIR      Themes directory (defaults to Warp's themes directory)")
+		fmt.Println()
+		fmt.Println("Conversion flags (interactive UI and convert):")
+		fmt.Println("  --derive-ansi             Derive missing ANSI colors from the theme's own colors")
+		fmt.Println("  --background-keys KEYS    VS Code keys tried in order for the background")
+		fmt.Println("                            (default terminal.background,panel.background,editor.background)")
+		fmt.Println("  --foreground-keys KEYS    VS Code keys tried in order for the foreground")
+		fmt.Println("                            (default terminal.foreground,editor.foreground)")
+		fmt.Println("  --ensure-contrast LEVEL   Repair ANSI colors below AA, AAA, AA-large, AAA-large or a ratio")
+		fmt.Println("  --gradient DIRECTION      Fade the background into the sidebar or title bar (vertical or horizontal)")
+		fmt.Println("  --on-conflict POLICY      skip, overwrite, rename or prompt when a file belongs to another theme")
+		fmt.Println("                            (default rename for convert, prompt in the interactive UI)")
+		fmt.Println("  --name-template TEXT      Name shown in Warp; {theme} is the label, {extension} the extension")
+		fmt.Println("  --background-image FILE   JPEG or PNG copied next to the theme and shown behind the terminal")
+		fmt.Println("  --image-opacity N         Opacity of the background image from 0 to 100 (default 100)")
+		fmt.Println("  --mapping FILE            YAML or JSON profile choosing the sources of each Warp color")
+		fmt.Println("  --overrides FILE          YAML or JSON file of per-theme values applied after conversion")
 		fmt.Println()
 		fmt.Println("Instructions:")
 		fmt.Println("  1. Run the command to see all available VS Code themes")
@@ -383,14 +664,29 @@ func main() {
 		fmt.Println("    /           Enter filter mode")
 		fmt.Println("    Enter       Apply filter and navigate results")
 		fmt.Println("    Esc         Cancel filter")
+		fmt.Println("    s           Cycle editor source (VS Code, Insiders, VSCodium, Cursor, Windsurf)")
 		fmt.Println("  Actions:")
 		fmt.Println("    Enter       Convert selected theme")
+		fmt.Println("    A           Convert all listed themes")
+		fmt.Println("    Esc         Return to the list after converting")
 		fmt.Println("    q           Quit")
 		fmt.Println("    Ctrl+C      Force quit")
 		return
 	}
 
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	// Conversion flags also apply to the interactive UI
+	var opts ConvertOptions
+	flags := flag.NewFlagSet("vscode-to-warp", flag.ExitOnError)
+	registerConversionFlags(flags, &opts)
+	flags.Parse(os.Args[1:])
+
+	// Anything left over is a misspelled or unknown command, not a reason to open the UI
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q, run with --help to see the commands\n", flags.Arg(0))
+		os.Exit(exitUsage)
+	}
+
+	p := tea.NewProgram(initialModel(opts), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
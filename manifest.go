@@ -0,0 +1,301 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestFilename is the record of generated themes kept in the themes directory. It is JSON
+// so Warp, which loads every .yaml file there, ignores it.
+const manifestFilename = ".vscode-to-warp.json"
+
+// Manifest records every theme file this tool generated in a themes directory
+type Manifest struct {
+	Themes []ManifestEntry `json:"themes"`
+}
+
+// ManifestEntry describes one generated theme file
+type ManifestEntry struct {
+	File             string    `json:"file"`            // Theme file name in the themes directory
+	Image            string    `json:"image,omitempty"` // Background image copied next to it
+	Name             string    `json:"name"`            // Name shown in Warp
+	Theme            string    `json:"theme"`           // VS Code theme label
+	SourcePath       string    `json:"source_path"`
+	ExtensionID      string    `json:"extension_id,omitempty"`
+	ExtensionVersion string    `json:"extension_version,omitempty"`
+	SHA256           string    `json:"sha256"` // Hash of the file as written, to detect later edits
+	ConvertedAt      time.Time `json:"converted_at"`
+}
+
+// Installed theme states reported by the installed command
+const (
+	installedOK       = "ok"
+	installedModified = "modified"
+	installedMissing  = "missing"
+)
+
+// loadManifest reads the manifest of themesDir; a directory without one has an empty manifest
+func loadManifest(themesDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(themesDir, manifestFilename))
+	if os.IsNotExist(err) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filepath.Join(themesDir, manifestFilename), err)
+	}
+	return manifest, nil
+}
+
+// save writes the manifest to themesDir, sorted by file name
+func (m *Manifest) save(themesDir string) error {
+	sort.Slice(m.Themes, func(i, j int) bool { return m.Themes[i].File < m.Themes[j].File })
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(themesDir, manifestFilename), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// put adds entry, replacing any earlier entry for the same file
+func (m *Manifest) put(entry ManifestEntry) {
+	for i, existing := range m.Themes {
+		if existing.File == entry.File {
+			m.Themes[i] = entry
+			return
+		}
+	}
+	m.Themes = append(m.Themes, entry)
+}
+
+// remove drops the entry for file
+func (m *Manifest) remove(file string) {
+	for i, entry := range m.Themes {
+		if entry.File == file {
+			m.Themes = append(m.Themes[:i], m.Themes[i+1:]...)
+			return
+		}
+	}
+}
+
+// find returns the entries whose file, file without extension, Warp name or VS Code label
+// equals query (case-insensitive)
+func (m *Manifest) find(query string) []ManifestEntry {
+	var matches []ManifestEntry
+	for _, entry := range m.Themes {
+		if strings.EqualFold(entry.File, query) ||
+			strings.EqualFold(strings.TrimSuffix(entry.File, filepath.Ext(entry.File)), query) ||
+			strings.EqualFold(entry.Name, query) ||
+			strings.EqualFold(entry.Theme, query) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// entry returns the entry for file
+func (m *Manifest) entry(file string) (ManifestEntry, bool) {
+	for _, entry := range m.Themes {
+		if entry.File == file {
+			return entry, true
+		}
+	}
+	return ManifestEntry{}, false
+}
+
+// imageInUse reports whether an entry other than file uses image
+func (m *Manifest) imageInUse(image, file string) bool {
+	for _, entry := range m.Themes {
+		if entry.Image == image && entry.File != file {
+			return true
+		}
+	}
+	return false
+}
+
+// recordConversion adds a theme written to path to the manifest of its directory, removing the
+// background image an earlier conversion of it copied when nothing uses it anymore
+func recordConversion(path, image string, themeInfo ThemeInfo, theme *WarpTheme) error {
+	hash, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+
+	entry := ManifestEntry{
+		File:        filepath.Base(path),
+		Image:       image,
+		Name:        theme.Name,
+		Theme:       themeInfo.Label,
+		SourcePath:  themeSourcePath(themeInfo),
+		SHA256:      hash,
+		ConvertedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if themeInfo.ExtensionMetadata != nil {
+		entry.ExtensionID = themeInfo.ExtensionMetadata.ID()
+		entry.ExtensionVersion = themeInfo.ExtensionMetadata.Version
+	}
+
+	themesDir := filepath.Dir(path)
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		return err
+	}
+	previous, converted := manifest.entry(entry.File)
+	manifest.put(entry)
+	if err := manifest.save(themesDir); err != nil {
+		return err
+	}
+
+	// An image the theme no longer shows would otherwise be left behind untracked
+	if converted && previous.Image != "" && previous.Image != entry.Image && !manifest.imageInUse(previous.Image, entry.File) {
+		if err := os.Remove(filepath.Join(themesDir, previous.Image)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", previous.Image, err)
+		}
+	}
+	return nil
+}
+
+// themeSourcePath returns where a theme was read from: its origin when its file is a temporary
+// copy, else the absolute path of its file
+func themeSourcePath(themeInfo ThemeInfo) string {
+	if themeInfo.Origin != "" {
+		return themeInfo.Origin
+	}
+	if path, err := filepath.Abs(themeInfo.Path); err == nil {
+		return path
+	}
+	return themeInfo.Path
+}
+
+// themeSource identifies the VS Code theme a file is converted from, whatever it is named or
+// attributed to: the extension id and label for extension themes, else the source path
+func themeSource(themeInfo ThemeInfo) string {
+	if themeInfo.ExtensionMetadata != nil && themeInfo.ExtensionMetadata.ID() != "" {
+		return themeInfo.ExtensionMetadata.ID() + "/" + themeInfo.Label
+	}
+	return themeSourcePath(themeInfo)
+}
+
+// source identifies the VS Code theme the entry's file was converted from, as themeSource does
+func (e ManifestEntry) source() string {
+	if e.ExtensionID != "" {
+		return e.ExtensionID + "/" + e.Theme
+	}
+	return e.SourcePath
+}
+
+// saveBackupEntry keeps the manifest entry of filename in themesDir next to its backup at
+// backupPath, so restoring the backup tracks the file again. Untracked files have no entry.
+func saveBackupEntry(themesDir, filename, backupPath string) error {
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		return err
+	}
+	entry, ok := manifest.entry(filename)
+	if !ok {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest entry: %w", err)
+	}
+	if err := writeFileAtomic(backupEntryPath(backupPath), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to back up manifest entry: %w", err)
+	}
+	return nil
+}
+
+// restoreBackupEntry updates the manifest of themesDir for a restored backup: the entry kept with
+// the backup replaces the current one, and a backup without one leaves its file untracked
+func restoreBackupEntry(themesDir string, backup themeBackup) error {
+	manifest, err := loadManifest(themesDir)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(backupEntryPath(backup.Path))
+	switch {
+	case os.IsNotExist(err):
+		if _, ok := manifest.entry(backup.Filename); !ok {
+			return nil
+		}
+		manifest.remove(backup.Filename)
+	case err != nil:
+		return fmt.Errorf("failed to read manifest entry of backup: %w", err)
+	default:
+		var entry ManifestEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("failed to parse manifest entry of backup %s: %w", backupEntryPath(backup.Path), err)
+		}
+		manifest.put(entry)
+	}
+	return manifest.save(themesDir)
+}
+
+// status reports whether the entry's file is unchanged since conversion, edited or gone
+func (e ManifestEntry) status(themesDir string) string {
+	hash, err := hashFile(filepath.Join(themesDir, e.File))
+	switch {
+	case err != nil:
+		return installedMissing
+	case hash != e.SHA256:
+		return installedModified
+	default:
+		return installedOK
+	}
+}
+
+// hashFile returns the hex SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// uninstallTheme removes a generated theme file, and its background image when no other theme
+// uses it, and drops it from the manifest. The theme file is backed up first so restore can bring
+// it back. Files edited since conversion are kept unless force is set.
+func uninstallTheme(themesDir string, manifest *Manifest, entry ManifestEntry, force bool) error {
+	path := filepath.Join(themesDir, entry.File)
+	switch entry.status(themesDir) {
+	case installedModified:
+		if !force {
+			return fmt.Errorf("%s was edited since it was converted, use --force to remove it anyway", entry.File)
+		}
+		fallthrough
+	case installedOK:
+		if _, err := backupThemeFile(path, nil); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", entry.File, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.File, err)
+		}
+	}
+
+	if entry.Image != "" && !manifest.imageInUse(entry.Image, entry.File) {
+		if err := os.Remove(filepath.Join(themesDir, entry.Image)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", entry.Image, err)
+		}
+	}
+
+	manifest.remove(entry.File)
+	return nil
+}
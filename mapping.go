@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MappingProfile declares, for every Warp theme color, the sources tried in order to fill it.
+// Profiles are loaded from YAML or JSON; fields a profile leaves out keep the default sources.
+type MappingProfile struct {
+	Accent         []ColorSource   `yaml:"accent,omitempty"`
+	Background     []ColorSource   `yaml:"background,omitempty"`
+	Foreground     []ColorSource   `yaml:"foreground,omitempty"`
+	TerminalColors MappingPalettes `yaml:"terminal_colors,omitempty"`
+}
+
+// MappingPalettes holds the sources of each ANSI slot, keyed by lowercase color name
+type MappingPalettes struct {
+	Normal map[string][]ColorSource `yaml:"normal,omitempty"`
+	Bright map[string][]ColorSource `yaml:"bright,omitempty"`
+}
+
+// ColorSource is one place a color can come from: a VS Code color key, the foreground of the
+// token rule that colors a TextMate scope, or a literal value. Transform optionally adjusts the
+// color found, e.g. "darken 0.1" or "alpha 0.5, saturate 0.2".
+type ColorSource struct {
+	Key       string `yaml:"key,omitempty"`
+	Scope     string `yaml:"scope,omitempty"`
+	Value     string `yaml:"value,omitempty"`
+	Transform string `yaml:"transform,omitempty"`
+}
+
+// Built-in fallbacks for each Warp color, used when a theme defines none of the mapped keys
+var (
+	defaultAccentKeys = []string{
+		"focusBorder",
+		"button.background",
+		"progressBar.background",
+		"textLink.foreground",
+		"editorCursor.foreground",
+		"terminal.ansiBlue",
+	}
+	defaultNormalColors = []string{"#1e1e1e", "#f44747", "#6a9955", "#dcdcaa", "#569cd6", "#c586c0", "#9cdcfe", "#d4d4d4"}
+	defaultBrightColors = []string{"#686868", "#f44747", "#6a9955", "#dcdcaa", "#569cd6", "#c586c0", "#9cdcfe", "#ffffff"}
+)
+
+// defaultMappingProfile returns the mapping used when no profile is given: VS Code's terminal and
+// workbench colors in order of relevance, then VS Code's Dark+ colors as literals. ANSI slots only
+// map their key, since a missing slot is first derived from its counterpart; the Dark+ literals
+// come after that.
+func defaultMappingProfile() *MappingProfile {
+	profile := &MappingProfile{
+		Accent:     append(keySources(defaultAccentKeys), ColorSource{Value: "#007acc"}),
+		Background: append(keySources(defaultBackgroundKeys), ColorSource{Value: "#1e1e1e"}),
+		Foreground: append(keySources(defaultForegroundKeys), ColorSource{Value: "#d4d4d4"}),
+		TerminalColors: MappingPalettes{
+			Normal: make(map[string][]ColorSource),
+			Bright: make(map[string][]ColorSource),
+		},
+	}
+	for _, name := range ansiColorNames {
+		slot := strings.ToLower(name)
+		profile.TerminalColors.Normal[slot] = []ColorSource{{Key: "terminal.ansi" + name}}
+		profile.TerminalColors.Bright[slot] = []ColorSource{{Key: "terminal.ansiBright" + name}}
+	}
+	return profile
+}
+
+// keySources turns a list of VS Code color keys into sources
+func keySources(keys []string) []ColorSource {
+	sources := make([]ColorSource, len(keys))
+	for i, key := range keys {
+		sources[i] = ColorSource{Key: key}
+	}
+	return sources
+}
+
+// sameSources reports whether two source lists are identical
+func sameSources(a, b []ColorSource) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withKeys replaces the VS Code keys among sources with keys, keeping scopes and literal values after them
+func withKeys(sources []ColorSource, keys []string) []ColorSource {
+	result := keySources(keys)
+	for _, source := range sources {
+		if source.Key == "" {
+			result = append(result, source)
+		}
+	}
+	return result
+}
+
+// LoadMappingProfile reads a mapping profile from a YAML or JSON file and fills the fields it
+// leaves out from the default profile
+func LoadMappingProfile(path string) (*MappingProfile, error) {
+	profile := &MappingProfile{}
+	if err := loadConfigFile(path, "mapping profile", profile); err != nil {
+		return nil, err
+	}
+
+	if err := profile.validate(); err != nil {
+		return nil, fmt.Errorf("invalid mapping profile %s: %w", path, err)
+	}
+
+	defaults := defaultMappingProfile()
+	if len(profile.Accent) == 0 {
+		profile.Accent = defaults.Accent
+	}
+	if len(profile.Background) == 0 {
+		profile.Background = defaults.Background
+	}
+	if len(profile.Foreground) == 0 {
+		profile.Foreground = defaults.Foreground
+	}
+	profile.TerminalColors.Normal = mergePalette(profile.TerminalColors.Normal, defaults.TerminalColors.Normal)
+	profile.TerminalColors.Bright = mergePalette(profile.TerminalColors.Bright, defaults.TerminalColors.Bright)
+
+	return profile, nil
+}
+
+// mergePalette fills the slots missing from palette with their default sources
+func mergePalette(palette, defaults map[string][]ColorSource) map[string][]ColorSource {
+	if palette == nil {
+		palette = make(map[string][]ColorSource)
+	}
+	for slot, sources := range defaults {
+		if len(palette[slot]) == 0 {
+			palette[slot] = sources
+		}
+	}
+	return palette
+}
+
+// validate checks every source of the profile and rejects unknown ANSI slots
+func (p *MappingProfile) validate() error {
+	fields := map[string][]ColorSource{
+		"accent":     p.Accent,
+		"background": p.Background,
+		"foreground": p.Foreground,
+	}
+	palettes := map[string]map[string][]ColorSource{
+		"normal": p.TerminalColors.Normal,
+		"bright": p.TerminalColors.Bright,
+	}
+	for paletteName, palette := range palettes {
+		for slot, sources := range palette {
+			if !isANSISlot(slot) {
+				return fmt.Errorf("terminal_colors.%s: unknown color %q", paletteName, slot)
+			}
+			fields["terminal_colors."+paletteName+"."+slot] = sources
+		}
+	}
+
+	for field, sources := range fields {
+		for i, source := range sources {
+			if err := source.validate(); err != nil {
+				return fmt.Errorf("%s[%d]: %w", field, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isANSISlot reports whether name is one of the eight lowercase ANSI color names
+func isANSISlot(name string) bool {
+	for _, slot := range ansiColorNames {
+		if strings.ToLower(slot) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that the source names exactly one origin and that its literal and transform parse
+func (s ColorSource) validate() error {
+	origins := 0
+	for _, origin := range []string{s.Key, s.Scope, s.Value} {
+		if origin != "" {
+			origins++
+		}
+	}
+	if origins != 1 {
+		return fmt.Errorf("expected exactly one of key, scope or value")
+	}
+	if s.Value != "" {
+		if _, err := parseColor(s.Value); err != nil {
+			return err
+		}
+	}
+	_, err := parseTransform(s.Transform)
+	return err
+}
+
+// String describes the source for reports
+func (s ColorSource) String() string {
+	switch {
+	case s.Key != "":
+		return s.Key
+	case s.Scope != "":
+		return "scope " + s.Scope
+	default:
+		return "value " + s.Value
+	}
+}
+
+// resolveColor returns the first source that yields a valid color, composited over background and
+// transformed, with a description of the source. Sources with invalid colors are reported against
+// the Warp field being filled and skipped.
+func resolveColor(field string, sources []ColorSource, theme *VSCodeTheme, colors map[string]string, background string, report *ConversionReport) (string, string, bool) {
+	for _, source := range sources {
+		var color string
+		switch {
+		case source.Key != "":
+			color = colors[source.Key]
+		case source.Scope != "":
+			color = tokenColorForScope(theme.TokenColors, source.Scope)
+		default:
+			color = source.Value
+		}
+		if color == "" {
+			continue
+		}
+
+		cleaned, err := cleanColor(color, background)
+		if err == nil {
+			cleaned, err = applyTransform(cleaned, source.Transform, background)
+		}
+		if err != nil {
+			report.warnf("%s: %s: %v, skipping", field, source, err)
+			continue
+		}
+		return cleaned, source.String(), true
+	}
+	return "", "", false
+}
+
+// tokenColorForScope returns the foreground a theme gives tokens of scope: the rule with the most
+// specific selector matching it, the later rule winning ties, the way VS Code picks it
+func tokenColorForScope(tokenColors []TokenColor, scope string) string {
+	color, bestLength := "", -1
+	for _, tokenColor := range tokenColors {
+		foreground := tokenColor.Settings["foreground"]
+		if foreground == "" {
+			continue
+		}
+		for _, selector := range tokenScopes(tokenColor) {
+			if selector != scope && !strings.HasPrefix(scope, selector+".") {
+				continue
+			}
+			if len(selector) >= bestLength {
+				color, bestLength = foreground, len(selector)
+			}
+		}
+	}
+	return color
+}
+
+// tokenScopes returns the selectors of a token rule, whose scope is a comma-separated string or a list
+func tokenScopes(tokenColor TokenColor) []string {
+	var selectors []string
+	switch scope := tokenColor.Scope.(type) {
+	case string:
+		selectors = strings.Split(scope, ",")
+	case []interface{}:
+		for _, s := range scope {
+			if str, ok := s.(string); ok {
+				selectors = append(selectors, str)
+			}
+		}
+	case []string:
+		selectors = scope
+	}
+	for i := range selectors {
+		selectors[i] = strings.TrimSpace(selectors[i])
+	}
+	return selectors
+}
+
+// colorTransform adjusts an opaque color, which is composited over background when it needs blending
+type colorTransform func(color rgbaColor, background rgbaColor) rgbaColor
+
+// transformOperations are the operations a source transform may chain, each taking an amount from 0 to 1
+var transformOperations = map[string]func(amount float64) colorTransform{
+	// lighten and darken move OKLCH lightness by amount
+	"lighten": func(amount float64) colorTransform {
+		return func(c, _ rgbaColor) rgbaColor { return shiftOKLCH(c, amount, 1) }
+	},
+	"darken": func(amount float64) colorTransform {
+		return func(c, _ rgbaColor) rgbaColor { return shiftOKLCH(c, -amount, 1) }
+	},
+	// saturate and desaturate scale OKLCH chroma by amount
+	"saturate": func(amount float64) colorTransform {
+		return func(c, _ rgbaColor) rgbaColor { return shiftOKLCH(c, 0, 1+amount) }
+	},
+	"desaturate": func(amount float64) colorTransform {
+		return func(c, _ rgbaColor) rgbaColor { return shiftOKLCH(c, 0, 1-amount) }
+	},
+	// alpha makes the color translucent over the background
+	"alpha": func(amount float64) colorTransform {
+		return func(c, background rgbaColor) rgbaColor {
+			c.A = amount
+			return c.over(background)
+		}
+	},
+}
+
+// shiftOKLCH moves a color's OKLCH lightness by delta and scales its chroma by chromaScale
+func shiftOKLCH(c rgbaColor, delta, chromaScale float64) rgbaColor {
+	shifted := c.oklch()
+	shifted.L += delta
+	shifted.C *= chromaScale
+	return shifted.rgba()
+}
+
+// parseTransform parses a comma-separated chain of operations such as "darken 0.1, alpha 0.8"
+func parseTransform(transform string) ([]colorTransform, error) {
+	var steps []colorTransform
+	for _, step := range strings.Split(transform, ",") {
+		fields := strings.Fields(step)
+		if len(fields) == 0 {
+			continue
+		}
+		operation, ok := transformOperations[fields[0]]
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("invalid transform %q: expected lighten, darken, saturate, desaturate or alpha followed by an amount", strings.TrimSpace(step))
+		}
+		amount, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || amount < 0 || amount > 1 {
+			return nil, fmt.Errorf("invalid transform %q: amount must be between 0 and 1", strings.TrimSpace(step))
+		}
+		steps = append(steps, operation(amount))
+	}
+	return steps, nil
+}
+
+// applyTransform applies a source transform to an opaque #rrggbb color
+func applyTransform(color, transform, background string) (string, error) {
+	if transform == "" {
+		return color, nil
+	}
+	steps, err := parseTransform(transform)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := parseColor(color)
+	if err != nil {
+		return "", err
+	}
+	base, err := parseColor(background)
+	if err != nil {
+		return "", fmt.Errorf("invalid background for blending: %w", err)
+	}
+	for _, step := range steps {
+		parsed = step(parsed, base)
+	}
+	return parsed.hex(), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ThemeOverrides maps a theme's label, display name, id or "<extension id>/<label>" to the Warp
+// values that replace the converted ones
+type ThemeOverrides map[string]ThemeOverride
+
+// ThemeOverride holds Warp theme values to apply after conversion; empty values are left alone
+type ThemeOverride struct {
+	Accent         WarpColor       `yaml:"accent,omitempty"`
+	Background     WarpColor       `yaml:"background,omitempty"`
+	Details        string          `yaml:"details,omitempty"`
+	Foreground     string          `yaml:"foreground,omitempty"`
+	TerminalColors PaletteOverride `yaml:"terminal_colors,omitempty"`
+}
+
+// PaletteOverride holds replacement ANSI colors keyed by lowercase color name
+type PaletteOverride struct {
+	Normal map[string]string `yaml:"normal,omitempty"`
+	Bright map[string]string `yaml:"bright,omitempty"`
+}
+
+// LoadThemeOverrides reads per-theme overrides from a YAML or JSON file
+func LoadThemeOverrides(path string) (ThemeOverrides, error) {
+	overrides := ThemeOverrides{}
+	if err := loadConfigFile(path, "overrides", &overrides); err != nil {
+		return nil, err
+	}
+
+	for key, override := range overrides {
+		if err := override.validate(); err != nil {
+			return nil, fmt.Errorf("invalid overrides %s: %s: %w", path, key, err)
+		}
+	}
+	return overrides, nil
+}
+
+// validate checks the override's colors, ANSI slot names and details value
+func (o ThemeOverride) validate() error {
+	if err := o.Accent.validate(); err != nil {
+		return fmt.Errorf("accent: %w", err)
+	}
+	if err := o.Background.validate(); err != nil {
+		return fmt.Errorf("background: %w", err)
+	}
+
+	colors := map[string]string{"foreground": o.Foreground}
+	palettes := map[string]map[string]string{
+		"normal": o.TerminalColors.Normal,
+		"bright": o.TerminalColors.Bright,
+	}
+	for paletteName, palette := range palettes {
+		for slot, color := range palette {
+			if !isANSISlot(slot) {
+				return fmt.Errorf("terminal_colors.%s: unknown color %q", paletteName, slot)
+			}
+			colors["terminal_colors."+paletteName+"."+slot] = color
+		}
+	}
+
+	for field, color := range colors {
+		if color == "" {
+			continue
+		}
+		if _, err := parseColor(color); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	if o.Details != "" && o.Details != "darker" && o.Details != "lighter" {
+		return fmt.Errorf("details: expected darker or lighter, got %q", o.Details)
+	}
+	return nil
+}
+
+// overrideKeys returns the keys a theme can be overridden by, from the least to the most specific
+func overrideKeys(themeInfo ThemeInfo) []string {
+	keys := []string{themeInfo.Label, themeInfo.DisplayName, themeInfo.ID}
+	if themeInfo.ExtensionMetadata != nil {
+		keys = append(keys, themeInfo.ExtensionMetadata.ID()+"/"+themeInfo.Label)
+	}
+	return keys
+}
+
+// matching returns the keys of the overrides matching themeInfo, from the least to the most specific
+func (overrides ThemeOverrides) matching(themeInfo ThemeInfo) []string {
+	var matched []string
+	seen := make(map[string]bool)
+	for _, key := range overrideKeys(themeInfo) {
+		for overrideKey := range overrides {
+			if key == "" || !strings.EqualFold(overrideKey, key) || seen[overrideKey] {
+				continue
+			}
+			seen[overrideKey] = true
+			matched = append(matched, overrideKey)
+		}
+	}
+	return matched
+}
+
+// apply patches theme with every override matching themeInfo, more specific keys winning, and
+// returns the keys that matched. Translucent colors are composited over the final background.
+func (overrides ThemeOverrides) apply(theme *WarpTheme, themeInfo ThemeInfo) []string {
+	applied := overrides.matching(themeInfo)
+	for _, key := range applied {
+		overrides[key].patch(theme)
+	}
+	return applied
+}
+
+// pinnedSlots returns the ANSI slots, as "normal.red" or "bright.red", that overrides matching
+// themeInfo set, so contrast repair leaves them as the user chose them
+func (overrides ThemeOverrides) pinnedSlots(themeInfo ThemeInfo) map[string]bool {
+	pinned := make(map[string]bool)
+	for _, key := range overrides.matching(themeInfo) {
+		override := overrides[key]
+		for slot, color := range override.TerminalColors.Normal {
+			pinned["normal."+slot] = color != ""
+		}
+		for slot, color := range override.TerminalColors.Bright {
+			pinned["bright."+slot] = color != ""
+		}
+	}
+	return pinned
+}
+
+// patch copies the override's values into theme
+func (o ThemeOverride) patch(theme *WarpTheme) {
+	// The background goes first since the other colors are blended over it
+	if !o.Background.IsZero() {
+		theme.Background, _ = o.Background.clean(theme.Background.base())
+		// Keep details in line with the new background unless it is overridden too
+		themeType := "dark"
+		if theme.Details == "lighter" {
+			themeType = "light"
+		}
+		theme.Details = detailsForBackground(theme.Background.base(), themeType)
+	}
+	background := theme.Background.base()
+	set := func(target *string, color string) {
+		if color != "" {
+			*target, _ = cleanColor(color, background)
+		}
+	}
+	if !o.Accent.IsZero() {
+		theme.Accent, _ = o.Accent.clean(background)
+	}
+	set(&theme.Foreground, o.Foreground)
+	if o.Details != "" {
+		theme.Details = o.Details
+	}
+
+	palettes := []struct {
+		palette   *ColorPalette
+		overrides map[string]string
+	}{
+		{&theme.TerminalColors.Normal, o.TerminalColors.Normal},
+		{&theme.TerminalColors.Bright, o.TerminalColors.Bright},
+	}
+	for _, p := range palettes {
+		for i, slot := range p.palette.slots() {
+			set(slot, p.overrides[strings.ToLower(ansiColorNames[i])])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"sort"
+)
+
+// ansiHues are the hues, in degrees, that the chromatic ANSI slots are matched against
+var ansiHues = []struct {
+	name string
+	hue  float64
+}{
+	{"Red", 0},
+	{"Yellow", 55},
+	{"Green", 120},
+	{"Cyan", 185},
+	{"Blue", 220},
+	{"Magenta", 300},
+}
+
+// Candidates further than this from a slot's hue are not used for it
+const maxHueDistance = 45.0
+
+// diagnosticColorKeys are editor colors whose hues are a good indication of the theme's palette
+var diagnosticColorKeys = []string{
+	"editorError.foreground",
+	"editorWarning.foreground",
+	"editorInfo.foreground",
+	"gitDecoration.addedResourceForeground",
+	"gitDecoration.modifiedResourceForeground",
+	"gitDecoration.deletedResourceForeground",
+}
+
+// paletteCandidate is a distinct color used by the theme, with how often it appears
+type paletteCandidate struct {
+	color rgbaColor
+	hue   float64
+	count int
+}
+
+// deriveANSIColors synthesizes the terminal.ansi* colors a theme leaves undefined from the
+// colors of its tokenColors and diagnostic editor colors, matching each chromatic slot by hue.
+// The returned map only contains normal slots missing from the theme; slots whose bright variant
+// is defined are left to be derived from it. White falls back to the editor foreground.
+func deriveANSIColors(theme *VSCodeTheme, background string) map[string]string {
+	derived := make(map[string]string)
+	candidates := collectPaletteCandidates(theme, background)
+
+	// Assign the closest hue matches first so each color is used for at most one slot
+	type match struct {
+		slot      string
+		candidate int
+		distance  float64
+	}
+	var matches []match
+	for _, slot := range ansiHues {
+		if definesANSISlot(theme, slot.name) {
+			continue
+		}
+		for i, candidate := range candidates {
+			if distance := hueDistance(slot.hue, candidate.hue); distance <= maxHueDistance {
+				matches = append(matches, match{slot: slot.name, candidate: i, distance: distance})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		// Prefer colors the theme uses more often
+		return candidates[matches[i].candidate].count > candidates[matches[j].candidate].count
+	})
+
+	usedCandidates := make(map[int]bool)
+	for _, m := range matches {
+		if _, done := derived["terminal.ansi"+m.slot]; done || usedCandidates[m.candidate] {
+			continue
+		}
+		usedCandidates[m.candidate] = true
+		derived["terminal.ansi"+m.slot] = candidates[m.candidate].color.hex()
+	}
+
+	if !definesANSISlot(theme, "White") && theme.Colors["editor.foreground"] != "" {
+		derived["terminal.ansiWhite"] = theme.Colors["editor.foreground"]
+	}
+
+	return derived
+}
+
+// definesANSISlot reports whether the theme defines the normal or bright color of an ANSI slot
+func definesANSISlot(theme *VSCodeTheme, name string) bool {
+	return theme.Colors["terminal.ansi"+name] != "" || theme.Colors["terminal.ansiBright"+name] != ""
+}
+
+// collectPaletteCandidates returns the distinct, reasonably saturated colors of a theme's
+// token rules and diagnostic editor colors, composited over background
+func collectPaletteCandidates(theme *VSCodeTheme, background string) []paletteCandidate {
+	var values []string
+	for _, tokenColor := range theme.TokenColors {
+		if foreground := tokenColor.Settings["foreground"]; foreground != "" {
+			values = append(values, foreground)
+		}
+	}
+	for _, key := range diagnosticColorKeys {
+		if color := theme.Colors[key]; color != "" {
+			values = append(values, color)
+		}
+	}
+
+	var candidates []paletteCandidate
+	index := make(map[string]int)
+	for _, value := range values {
+		hex, err := cleanColor(value, background)
+		if err != nil {
+			continue
+		}
+		if i, seen := index[hex]; seen {
+			candidates[i].count++
+			continue
+		}
+
+		color, _ := parseColor(hex)
+		hue, saturation, lightness := color.hsl()
+		// Greys and near black or white colors say nothing about the palette
+		if saturation < 0.15 || lightness < 0.2 || lightness > 0.85 {
+			continue
+		}
+
+		index[hex] = len(candidates)
+		candidates = append(candidates, paletteCandidate{color: color, hue: hue, count: 1})
+	}
+
+	return candidates
+}
+
+// ansiColorNames are the eight ANSI slots in palette order
+var ansiColorNames = []string{"Black", "Red", "Green", "Yellow", "Blue", "Magenta", "Cyan", "White"}
+
+// brightLightnessStep is how far, in OKLCH lightness, bright variants sit from normal colors
+const brightLightnessStep = 0.1
+
+// deriveANSIVariants fills in the empty bright slot of every ANSI color whose normal slot is set,
+// and the empty normal slot of every color whose bright slot is set. Variants are made by shifting
+// OKLCH lightness away from the background: lighter on dark themes, darker on light ones.
+func deriveANSIVariants(colors *TerminalColors, background string) {
+	base, err := parseColor(background)
+	if err != nil {
+		return
+	}
+	step := brightLightnessStep
+	if !base.isDark() {
+		step = -step
+	}
+
+	normals, brights := colors.Normal.slots(), colors.Bright.slots()
+	for i := range ansiColorNames {
+		normal, bright := normals[i], brights[i]
+
+		switch {
+		case *normal != "" && *bright == "":
+			if color, err := shiftLightness(*normal, step, background); err == nil {
+				*bright = color
+			}
+		case *bright != "" && *normal == "":
+			if color, err := shiftLightness(*bright, -step, background); err == nil {
+				*normal = color
+			}
+		}
+	}
+}
+
+// shiftLightness returns color, composited over background, with its OKLCH lightness changed by delta
+func shiftLightness(color string, delta float64, background string) (string, error) {
+	cleaned, err := cleanColor(color, background)
+	if err != nil {
+		return "", err
+	}
+	parsed, _ := parseColor(cleaned)
+
+	shifted := parsed.oklch()
+	shifted.L += delta
+	return shifted.rgba().hex(), nil
+}
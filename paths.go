@@ -12,26 +12,124 @@ func getPlatformInfo() (string, string) {
 	return runtime.GOOS, runtime.GOARCH
 }
 
-// getVSCodeExtensionsPath returns the VS Code extensions directory path for the current platform
-func getVSCodeExtensionsPath() (string, error) {
+// EditorSource describes an editor whose extensions directory may contain themes
+type EditorSource struct {
+	ID            string // Short identifier used for filtering, e.g. "cursor"
+	Name          string // Human readable editor name
+	ExtensionsDir string
+}
+
+// editorExtensionDirs lists the known VS Code compatible editors and their extension
+// directories relative to the home directory. The layout is the same on every platform.
+var editorExtensionDirs = []struct {
+	id, name, dir string
+}{
+	{"vscode", "VS Code", ".vscode"},
+	{"insiders", "VS Code Insiders", ".vscode-insiders"},
+	{"vscodium", "VSCodium", ".vscode-oss"},
+	{"cursor", "Cursor", ".cursor"},
+	{"windsurf", "Windsurf", ".windsurf"},
+}
+
+// getEditorSources returns the extension directories of every known editor for the current platform
+func getEditorSources() ([]EditorSource, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	// Windows: %USERPROFILE%\.vscode\extensions, macOS and Linux: ~/.vscode/extensions
+	sources := make([]EditorSource, 0, len(editorExtensionDirs))
+	for _, editor := range editorExtensionDirs {
+		sources = append(sources, EditorSource{
+			ID:            editor.id,
+			Name:          editor.name,
+			ExtensionsDir: filepath.Join(homeDir, editor.dir, "extensions"),
+		})
+	}
+
+	// Built-in themes ship inside the application rather than the user's extensions directory
+	for _, dir := range getBuiltinExtensionsPaths() {
+		sources = append(sources, EditorSource{
+			ID:            builtinSourceID,
+			Name:          "Built-in",
+			ExtensionsDir: dir,
+		})
 	}
+	return sources, nil
+}
+
+// isEditorSource reports whether id names a known editor source
+func isEditorSource(id string) bool {
+	if id == builtinSourceID {
+		return true
+	}
+	for _, editor := range editorExtensionDirs {
+		if editor.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinSourceID identifies themes shipped with the VS Code application itself
+const builtinSourceID = "builtin"
+
+// builtinExtensionsEnv overrides the built-in extension directories with a path list
+const builtinExtensionsEnv = "VSCODE_TO_WARP_BUILTIN_DIRS"
+
+// getBuiltinExtensionsPaths returns the VS Code application's built-in extensions directories.
+// The directories in VSCODE_TO_WARP_BUILTIN_DIRS are used when set, otherwise the first
+// existing default install location for the current platform.
+func getBuiltinExtensionsPaths() []string {
+	if configured := os.Getenv(builtinExtensionsEnv); configured != "" {
+		var dirs []string
+		for _, dir := range filepath.SplitList(configured) {
+			if dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+		return dirs
+	}
+
+	// Several installs of the same application would only produce duplicate themes
+	for _, dir := range defaultBuiltinExtensionsPaths() {
+		if stat, err := os.Stat(dir); err == nil && stat.IsDir() {
+			return []string{dir}
+		}
+	}
+	return nil
+}
+
+// defaultBuiltinExtensionsPaths lists the usual VS Code install locations for the current platform
+func defaultBuiltinExtensionsPaths() []string {
+	homeDir, _ := os.UserHomeDir()
+	appExtensions := filepath.Join("resources", "app", "extensions")
 
 	switch runtime.GOOS {
 	case "windows":
-		// Windows: %USERPROFILE%\.vscode\extensions
-		return filepath.Join(homeDir, ".vscode", "extensions"), nil
+		// Windows: user and system installs
+		return []string{
+			filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "Microsoft VS Code", appExtensions),
+			filepath.Join(os.Getenv("ProgramFiles"), "Microsoft VS Code", appExtensions),
+		}
 	case "darwin":
-		// macOS: ~/.vscode/extensions
-		return filepath.Join(homeDir, ".vscode", "extensions"), nil
-	case "linux":
-		// Linux: ~/.vscode/extensions
-		return filepath.Join(homeDir, ".vscode", "extensions"), nil
+		// macOS: application bundle
+		return []string{
+			filepath.Join("/Applications", "Visual Studio Code.app", "Contents", "Resources", "app", "extensions"),
+			filepath.Join(homeDir, "Applications", "Visual Studio Code.app", "Contents", "Resources", "app", "extensions"),
+		}
 	default:
-		// Try the standard Unix path for unknown systems
-		return filepath.Join(homeDir, ".vscode", "extensions"), nil
+		// Linux: deb/rpm packages, snap and flatpak (system and user) installs
+		flatpakApp := filepath.Join("app", "com.visualstudio.code", "current", "active", "files", "extra", "vscode", appExtensions)
+		return []string{
+			filepath.Join("/usr", "share", "code", appExtensions),
+			filepath.Join("/usr", "lib", "code", appExtensions),
+			filepath.Join("/opt", "visual-studio-code", appExtensions),
+			filepath.Join("/snap", "code", "current", "usr", "share", "code", appExtensions),
+			filepath.Join("/var", "lib", "flatpak", flatpakApp),
+			filepath.Join(homeDir, ".local", "share", "flatpak", flatpakApp),
+		}
 	}
 }
 
@@ -65,6 +163,18 @@ func getWarpThemesPath() (string, error) {
 	}
 }
 
+// resolveThemesDir returns dir, or Warp's themes directory when dir is empty
+func resolveThemesDir(dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+	warpThemesDir, err := getWarpThemesPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Warp themes directory: %w", err)
+	}
+	return warpThemesDir, nil
+}
+
 // isThemesDirectory checks if a path contains a themes directory (cross-platform)
 func isThemesDirectory(path string) bool {
 	// Use filepath.Separator to handle both / and \ separators
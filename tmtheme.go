@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tmThemeEditorColors maps the global settings of a TextMate theme to VS Code editor color keys
+var tmThemeEditorColors = map[string]string{
+	"background":    "editor.background",
+	"foreground":    "editor.foreground",
+	"caret":         "editorCursor.foreground",
+	"selection":     "editor.selectionBackground",
+	"lineHighlight": "editor.lineHighlightBackground",
+	"invisibles":    "editorWhitespace.foreground",
+	"findHighlight": "editor.findMatchHighlightBackground",
+	"guide":         "editorIndentGuide.background",
+	"activeGuide":   "editorIndentGuide.activeBackground",
+}
+
+// isTmThemeFile reports whether path is a TextMate theme
+func isTmThemeFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".tmtheme")
+}
+
+// loadTmTheme parses a TextMate .tmTheme plist into a VS Code theme. Rules with a scope become
+// tokenColors, the global rule without a scope provides the editor colors.
+func loadTmTheme(path string) (*VSCodeTheme, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme file: %w", err)
+	}
+	defer file.Close()
+
+	plist, err := parsePlist(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tmTheme: %w", err)
+	}
+
+	root, ok := plist.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse tmTheme: root element is not a dict")
+	}
+
+	theme := &VSCodeTheme{Colors: make(map[string]string)}
+	theme.Name, _ = root["name"].(string)
+
+	rules, _ := root["settings"].([]interface{})
+	for _, rawRule := range rules {
+		rule, ok := rawRule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		settings := plistStrings(rule["settings"])
+		scope, _ := rule["scope"].(string)
+
+		if scope == "" {
+			// Global settings
+			for setting, colorKey := range tmThemeEditorColors {
+				if color, ok := settings[setting]; ok && color != "" {
+					theme.Colors[colorKey] = color
+				}
+			}
+			continue
+		}
+
+		name, _ := rule["name"].(string)
+		theme.TokenColors = append(theme.TokenColors, TokenColor{
+			Name:     name,
+			Scope:    scope,
+			Settings: settings,
+		})
+	}
+
+	return theme, nil
+}
+
+// plistStrings returns the string values of a plist dict, ignoring any other value types
+func plistStrings(value interface{}) map[string]string {
+	result := make(map[string]string)
+	dict, _ := value.(map[string]interface{})
+	for key, raw := range dict {
+		if str, ok := raw.(string); ok {
+			result[key] = strings.TrimSpace(str)
+		}
+	}
+	return result
+}
+
+// parsePlist decodes an XML property list into maps, slices, strings, and bools.
+// Numbers and dates are kept as their string representation.
+func parsePlist(r io.Reader) (interface{}, error) {
+	decoder := xml.NewDecoder(r)
+	// Plists declare a DOCTYPE and are nearly always UTF-8
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no plist element found")
+			}
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "plist" {
+			// The plist element wraps a single value
+			for {
+				token, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				switch t := token.(type) {
+				case xml.StartElement:
+					return parsePlistValue(decoder, t)
+				case xml.EndElement:
+					return nil, fmt.Errorf("empty plist")
+				}
+			}
+		}
+	}
+}
+
+// parsePlistValue decodes the value starting at start, consuming tokens up to its end element
+func parsePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		key := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					var text string
+					if err := decoder.DecodeElement(&text, &t); err != nil {
+						return nil, err
+					}
+					key = text
+					continue
+				}
+				value, err := parsePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []interface{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := parsePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	default:
+		// string, integer, real, date and data
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		return text, nil
+	}
+}
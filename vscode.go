@@ -17,6 +17,15 @@ type VSCodeTheme struct {
 	TokenColors []TokenColor       `json:"tokenColors,omitempty"`
 }
 
+// themeFile is a theme file as written on disk, before includes and token color files are resolved
+type themeFile struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Include     string            `json:"include"` // Base theme, relative to this file
+	Colors      map[string]string `json:"colors"`
+	TokenColors json.RawMessage   `json:"tokenColors"` // Array of rules or a path to a rules file
+}
+
 // TokenColor represents syntax highlighting colors
 type TokenColor struct {
 	Name     string                 `json:"name,omitempty"`
@@ -38,34 +47,126 @@ type ExtensionMetadata struct {
 		Type string `json:"type"`
 		URL  string `json:"url"`
 	} `json:"repository"`
+	Contributes struct {
+		Themes []ThemeContribution `json:"themes"`
+	} `json:"contributes"`
+}
+
+// ThemeContribution is a color theme declared in an extension's package.json contributes.themes
+type ThemeContribution struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	UITheme string `json:"uiTheme"` // "vs", "vs-dark", "hc-black" or "hc-light"
+	Path    string `json:"path"`    // Relative to the extension directory
+}
+
+// ID returns the extension identifier in publisher.name form
+func (m *ExtensionMetadata) ID() string {
+	if m.Publisher == "" {
+		return m.Name
+	}
+	return m.Publisher + "." + m.Name
 }
 
 // ThemeInfo holds information about a discoverable theme
 type ThemeInfo struct {
-	Name        string
-	DisplayName string
-	Path        string
-	Type        string // "dark" or "light"
+	Name              string
+	ID                string // Theme id from package.json, empty when the contribution declares none
+	Label             string // Theme label from package.json, or the name declared by the theme itself
+	DisplayName       string
+	Path              string
+	Type              string             // "dark" or "light"
+	Source            string             // Editor source ID, e.g. "vscode" or "cursor"
+	SourceName        string             // Human readable editor name
+	Origin            string             // Where the theme was read from when Path is a temporary copy
 	ExtensionMetadata *ExtensionMetadata // Optional extension metadata
 }
 
-// DiscoverVSCodeThemes finds all VS Code themes in the extensions directory
+// DiscoverVSCodeThemes finds all themes in the extensions directories of every known editor
 func DiscoverVSCodeThemes() ([]ThemeInfo, error) {
-	extensionsPath, err := getVSCodeExtensionsPath()
+	sources, err := getEditorSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get editor extensions paths: %w", err)
+	}
+
+	var themes []ThemeInfo
+	for _, source := range sources {
+		sourceThemes, err := discoverThemesIn(source)
+		if err != nil {
+			// One unreadable editor should not hide the themes of the others
+			fmt.Fprintf(os.Stderr, "Warning: %v, skipping %s themes\n", err, source.Name)
+			continue
+		}
+		themes = append(themes, sourceThemes...)
+	}
+
+	return themes, nil
+}
+
+// discoverThemesIn finds all themes in a single editor's extensions directory
+func discoverThemesIn(source EditorSource) ([]ThemeInfo, error) {
+	entries, err := os.ReadDir(source.ExtensionsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Editor is not installed
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s extensions directory: %w", source.Name, err)
+	}
+
+	var themes []ThemeInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		for _, themeInfo := range discoverExtensionThemes(filepath.Join(source.ExtensionsDir, entry.Name())) {
+			themeInfo.Source = source.ID
+			themeInfo.SourceName = source.Name
+			// Tag themes from other editors so duplicates across editors stay distinguishable
+			if source.ID != "vscode" {
+				themeInfo.DisplayName = fmt.Sprintf("%s [%s]", themeInfo.DisplayName, source.Name)
+			}
+			themes = append(themes, themeInfo)
+		}
+	}
+
+	return themes, nil
+}
+
+// discoverExtensionThemes finds the themes of a single extension. The themes listed in the
+// extension's package.json are authoritative; without a manifest, JSON files under a themes
+// directory are tried instead.
+func discoverExtensionThemes(extensionDir string) []ThemeInfo {
+	metadata, err := loadPackageJSON(extensionDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get VS Code extensions path: %w", err)
+		return discoverThemesByPath(extensionDir)
 	}
+
 	var themes []ThemeInfo
+	for _, contribution := range metadata.Contributes.Themes {
+		themeInfo, err := parseContributedTheme(extensionDir, metadata, contribution)
+		if err != nil {
+			// Skip missing or invalid theme files
+			continue
+		}
+		themes = append(themes, *themeInfo)
+	}
+	return themes
+}
 
-	// Walk through all extension directories
-	err = filepath.WalkDir(extensionsPath, func(path string, d fs.DirEntry, err error) error {
+// discoverThemesByPath finds theme JSON and .tmTheme files in themes directories below dir
+func discoverThemesByPath(dir string) []ThemeInfo {
+	var themes []ThemeInfo
+
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			// Skip directories we can't access
 			return nil
 		}
 
-		// Look for theme JSON files in themes directories
-		if d.IsDir() || !strings.HasSuffix(strings.ToLower(path), ".json") {
+		// Look for theme JSON and TextMate files in themes directories
+		if d.IsDir() || !(strings.HasSuffix(strings.ToLower(path), ".json") || isTmThemeFile(path)) {
 			return nil
 		}
 
@@ -85,22 +186,58 @@ func DiscoverVSCodeThemes() ([]ThemeInfo, error) {
 		return nil
 	})
 
+	return themes
+}
+
+// parseContributedTheme builds the theme info for a theme declared in an extension's package.json
+func parseContributedTheme(extensionDir string, metadata *ExtensionMetadata, contribution ThemeContribution) (*ThemeInfo, error) {
+	if contribution.Path == "" {
+		return nil, fmt.Errorf("theme contribution has no path")
+	}
+	path := filepath.Join(extensionDir, filepath.FromSlash(contribution.Path))
+
+	theme, err := LoadVSCodeTheme(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk extensions directory: %w", err)
+		return nil, err
 	}
 
-	return themes, nil
+	label := resolveNLSString(extensionDir, contribution.Label)
+	if label == "" {
+		label = theme.Name
+	}
+	if label == "" {
+		return nil, fmt.Errorf("theme has no label or name")
+	}
+
+	// VS Code itself goes by the uiTheme, the theme file's own type is only a fallback
+	themeType := normalizeThemeType(contribution.UITheme)
+	if themeType == "" {
+		themeType = normalizeThemeType(theme.Type)
+	}
+
+	themeInfo := newThemeInfo(path, label, themeType)
+	themeInfo.ID = contribution.ID
+	themeInfo.ExtensionMetadata = metadata
+	return themeInfo, nil
 }
 
-// parseThemeFile parses a VS Code theme JSON file and extracts basic info
-func parseThemeFile(path string) (*ThemeInfo, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+// normalizeThemeType maps a package.json uiTheme or a theme file's type, including
+// the high contrast variants, to "dark" or "light"
+func normalizeThemeType(themeType string) string {
+	switch strings.ToLower(themeType) {
+	case "vs", "light", "hc-light", "hclight":
+		return "light"
+	case "vs-dark", "dark", "hc-black", "hc", "hcdark":
+		return "dark"
+	default:
+		return ""
 	}
+}
 
-	var theme VSCodeTheme
-	if err := json.Unmarshal(data, &theme); err != nil {
+// parseThemeFile parses a VS Code theme JSON or .tmTheme file and extracts basic info
+func parseThemeFile(path string) (*ThemeInfo, error) {
+	theme, err := LoadVSCodeTheme(path)
+	if err != nil {
 		return nil, err
 	}
 
@@ -109,21 +246,33 @@ func parseThemeFile(path string) (*ThemeInfo, error) {
 		return nil, fmt.Errorf("theme has no name")
 	}
 
-	// Generate a clean display name from the file path and theme name
+	themeInfo := newThemeInfo(path, theme.Name, normalizeThemeType(theme.Type))
+
+	// Extension metadata is optional, themes outside an extension have none
+	if metadata, err := LoadExtensionMetadata(path); err == nil {
+		themeInfo.ExtensionMetadata = metadata
+	}
+
+	return themeInfo, nil
+}
+
+// newThemeInfo builds the theme info for a theme file, generating a display name from its label and extension
+func newThemeInfo(path, label, themeType string) *ThemeInfo {
 	filename := filepath.Base(path)
 	extensionName := extractExtensionName(path)
-	
-	displayName := theme.Name
+
+	displayName := label
 	if extensionName != "" {
-		displayName = fmt.Sprintf("%s (%s)", theme.Name, extensionName)
+		displayName = fmt.Sprintf("%s (%s)", label, extensionName)
 	}
 
 	return &ThemeInfo{
-		Name:        strings.TrimSuffix(filename, ".json"),
+		Name:        strings.TrimSuffix(filename, filepath.Ext(filename)),
+		Label:       label,
 		DisplayName: displayName,
 		Path:        path,
-		Type:        theme.Type,
-	}, nil
+		Type:        themeType,
+	}
 }
 
 // extractExtensionName extracts the extension name from the path
@@ -158,19 +307,112 @@ func extractExtensionName(path string) string {
 	return ""
 }
 
-// LoadVSCodeTheme loads and parses a VS Code theme file
+// LoadVSCodeTheme loads and parses a VS Code theme file, resolving its include chain
+// and any token colors kept in a separate file. TextMate .tmTheme files are converted.
 func LoadVSCodeTheme(path string) (*VSCodeTheme, error) {
-	data, err := os.ReadFile(path)
+	if isTmThemeFile(path) {
+		return loadTmTheme(path)
+	}
+	return loadThemeWithIncludes(path, nil)
+}
+
+// loadThemeWithIncludes loads the theme at path on top of the theme it includes.
+// chain holds the files already being loaded and is used to detect include cycles.
+func loadThemeWithIncludes(path string, chain []string) (*VSCodeTheme, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve theme path: %w", err)
+	}
+	for _, loading := range chain {
+		if loading == absPath {
+			return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(chain, " -> "), absPath)
+		}
+	}
+	chain = append(chain, absPath)
+
+	data, err := os.ReadFile(absPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read theme file: %w", err)
 	}
 
-	var theme VSCodeTheme
-	if err := json.Unmarshal(data, &theme); err != nil {
+	var file themeFile
+	if err := unmarshalJSONC(data, &file); err != nil {
 		return nil, fmt.Errorf("failed to parse theme JSON: %w", err)
 	}
 
-	return &theme, nil
+	// Start from the included base theme, if any
+	theme := &VSCodeTheme{Colors: make(map[string]string)}
+	if file.Include != "" {
+		base, err := loadThemeWithIncludes(filepath.Join(filepath.Dir(absPath), filepath.FromSlash(file.Include)), chain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load included theme %s: %w", file.Include, err)
+		}
+		theme = base
+	}
+
+	// The including theme's values take precedence over the base theme's
+	if file.Name != "" {
+		theme.Name = file.Name
+	}
+	if file.Type != "" {
+		theme.Type = file.Type
+	}
+	for key, color := range file.Colors {
+		theme.Colors[key] = color
+	}
+
+	tokenColors, err := loadTokenColors(absPath, file.TokenColors)
+	if err != nil {
+		return nil, err
+	}
+	// Later rules win in VS Code, so the including theme's rules go after the base theme's
+	theme.TokenColors = append(theme.TokenColors, tokenColors...)
+
+	return theme, nil
+}
+
+// loadTokenColors decodes a theme's tokenColors value, which is either an array of rules
+// or a path, relative to the theme file, to a file containing them
+func loadTokenColors(themePath string, raw json.RawMessage) ([]TokenColor, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var tokenColors []TokenColor
+	if err := json.Unmarshal(raw, &tokenColors); err == nil {
+		return tokenColors, nil
+	}
+
+	var tokenColorsPath string
+	if err := json.Unmarshal(raw, &tokenColorsPath); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenColors: expected an array or a file path")
+	}
+	tokenColorsPath = filepath.Join(filepath.Dir(themePath), filepath.FromSlash(tokenColorsPath))
+
+	switch strings.ToLower(filepath.Ext(tokenColorsPath)) {
+	case ".json":
+		// Either a bare array of rules or a theme with its own tokenColors
+		data, err := os.ReadFile(tokenColorsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token colors file: %w", err)
+		}
+		if err := unmarshalJSONC(data, &tokenColors); err == nil {
+			return tokenColors, nil
+		}
+		theme, err := loadThemeWithIncludes(tokenColorsPath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load token colors file: %w", err)
+		}
+		return theme.TokenColors, nil
+	case ".tmtheme":
+		theme, err := loadTmTheme(tokenColorsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load token colors file: %w", err)
+		}
+		return theme.TokenColors, nil
+	default:
+		return nil, fmt.Errorf("unsupported token colors file: %s", filepath.Base(tokenColorsPath))
+	}
 }
 
 // LoadExtensionMetadata loads the package.json metadata for an extension from a theme path
@@ -196,19 +438,56 @@ func LoadExtensionMetadata(themePath string) (*ExtensionMetadata, error) {
 	
 	// Build the path to the extension directory
 	extensionDir := strings.Join(parts[:extensionDirIndex+1], string(filepath.Separator))
-	packageJSONPath := filepath.Join(extensionDir, "package.json")
-	
-	// Try to read the package.json file
-	data, err := os.ReadFile(packageJSONPath)
+	return loadPackageJSON(extensionDir)
+}
+
+// loadPackageJSON reads the package.json manifest of an extension directory
+func loadPackageJSON(extensionDir string) (*ExtensionMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(extensionDir, "package.json"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read package.json: %w", err)
 	}
-	
+
 	var metadata ExtensionMetadata
-	if err := json.Unmarshal(data, &metadata); err != nil {
+	if err := unmarshalJSONC(data, &metadata); err != nil {
 		return nil, fmt.Errorf("failed to parse package.json: %w", err)
 	}
-	
+
+	metadata.DisplayName = resolveNLSString(extensionDir, metadata.DisplayName)
 	return &metadata, nil
 }
 
+// resolveNLSString replaces a "%key%" placeholder, as used by built-in extensions,
+// with its message from the extension's package.nls.json
+func resolveNLSString(extensionDir, value string) string {
+	if len(value) < 3 || !strings.HasPrefix(value, "%") || !strings.HasSuffix(value, "%") {
+		return value
+	}
+
+	data, err := os.ReadFile(filepath.Join(extensionDir, "package.nls.json"))
+	if err != nil {
+		return value
+	}
+
+	// Messages are either plain strings or objects with a message and a translator comment
+	var messages map[string]json.RawMessage
+	if err := unmarshalJSONC(data, &messages); err != nil {
+		return value
+	}
+	raw, ok := messages[value[1:len(value)-1]]
+	if !ok {
+		return value
+	}
+
+	var message string
+	if err := json.Unmarshal(raw, &message); err == nil {
+		return message
+	}
+	var withComment struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &withComment); err == nil && withComment.Message != "" {
+		return withComment.Message
+	}
+	return value
+}
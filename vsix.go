@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vsixSourceID identifies themes read from a .vsix package rather than an editor
+const vsixSourceID = "vsix"
+
+// LoadVSIXThemes lists the themes contributed by a .vsix extension package without installing it.
+// The package is unpacked to a temporary directory that stays readable until cleanup is called.
+func LoadVSIXThemes(vsixPath string) (themes []ThemeInfo, cleanup func(), err error) {
+	tempDir, err := os.MkdirTemp("", "vscode-to-warp-vsix-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tempDir) }
+
+	if err := extractVSIX(vsixPath, tempDir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	// The extension itself lives in the extension/ folder of the package
+	extensionDir := filepath.Join(tempDir, "extension")
+	metadata, err := loadPackageJSON(extensionDir)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("invalid .vsix package: %w", err)
+	}
+
+	// Record where the package was read from independently of the current directory
+	packagePath, err := filepath.Abs(vsixPath)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to resolve %s: %w", vsixPath, err)
+	}
+	packageName := filepath.Base(packagePath)
+	for _, contribution := range metadata.Contributes.Themes {
+		themeInfo, err := parseContributedTheme(extensionDir, metadata, contribution)
+		if err != nil {
+			// Skip missing or invalid theme files
+			continue
+		}
+
+		// The temporary path says nothing about the extension, so name it from its manifest
+		themeInfo.DisplayName = fmt.Sprintf("%s (%s)", themeInfo.Label, formatExtensionName(metadata))
+		themeInfo.Source = vsixSourceID
+		themeInfo.SourceName = packageName
+		if relPath, err := filepath.Rel(tempDir, themeInfo.Path); err == nil {
+			themeInfo.Origin = packagePath + ":" + filepath.ToSlash(relPath)
+		}
+		themes = append(themes, *themeInfo)
+	}
+
+	if len(themes) == 0 {
+		cleanup()
+		return nil, nil, fmt.Errorf("%s does not contribute any themes", packageName)
+	}
+
+	return themes, cleanup, nil
+}
+
+// extractVSIX unpacks the extension/ folder of a .vsix package into destDir
+func extractVSIX(vsixPath, destDir string) error {
+	reader, err := zip.OpenReader(vsixPath)
+	if err != nil {
+		return fmt.Errorf("failed to open .vsix package: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if !strings.HasPrefix(file.Name, "extension/") || file.FileInfo().IsDir() {
+			continue
+		}
+
+		// Refuse entries that would escape the destination directory
+		target := filepath.Join(destDir, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file path in .vsix package: %s", file.Name)
+		}
+
+		if err := extractZipFile(file, target); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// extractZipFile writes a single zip entry to target, creating parent directories as needed
+func extractZipFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// formatExtensionName describes an extension as "Display Name by Publisher" from its manifest
+func formatExtensionName(metadata *ExtensionMetadata) string {
+	name := metadata.DisplayName
+	if name == "" {
+		name = metadata.Name
+	}
+	if metadata.Publisher == "" {
+		return name
+	}
+	return fmt.Sprintf("%s by %s", name, metadata.Publisher)
+}
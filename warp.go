@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,12 +12,15 @@ import (
 
 // WarpTheme represents a Warp theme YAML structure
 type WarpTheme struct {
-	Accent         string         `yaml:"accent"`
-	Background     string         `yaml:"background"`
+	Name           string         `yaml:"name,omitempty"`
+	Accent         WarpColor      `yaml:"accent"`
+	Background     WarpColor      `yaml:"background"`
 	Details        string         `yaml:"details"`
 	Foreground     string         `yaml:"foreground"`
 	TerminalColors TerminalColors `yaml:"terminal_colors"`
 	BasedOn        string         `yaml:"based_on,omitempty"`
+
+	BackgroundImage *BackgroundImage `yaml:"background_image,omitempty"`
 }
 
 // TerminalColors represents the terminal color palette
@@ -37,138 +41,240 @@ type ColorPalette struct {
 	White   string `yaml:"white"`
 }
 
+// ConversionReport collects notes about a conversion that did not prevent it
+type ConversionReport struct {
+	Warnings      []string
+	BackgroundKey string   // Mapping source the background came from
+	ForegroundKey string   // Mapping source the foreground came from
+	Overrides     []string // Keys of the overrides applied to the theme
+	Contrast      []ContrastCheck
+}
+
+// Default source priority for the background and foreground. The integrated terminal
+// sits on the panel, so its own colors describe what users see in a terminal best.
+var (
+	defaultBackgroundKeys = []string{"terminal.background", "panel.background", "editor.background"}
+	defaultForegroundKeys = []string{"terminal.foreground", "editor.foreground"}
+)
+
+// warnf records a warning, once however often it comes up
+func (r *ConversionReport) warnf(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	for _, existing := range r.Warnings {
+		if existing == warning {
+			return
+		}
+	}
+	r.Warnings = append(r.Warnings, warning)
+}
+
 // ConvertVSCodeToWarp converts a VS Code theme to Warp theme format
-func ConvertVSCodeToWarp(vscodeTheme *VSCodeTheme, extensionMetadata *ExtensionMetadata) (*WarpTheme, error) {
+func ConvertVSCodeToWarp(vscodeTheme *VSCodeTheme, extensionMetadata *ExtensionMetadata, opts ConvertOptions) (*WarpTheme, *ConversionReport, error) {
 	warpTheme := &WarpTheme{}
+	report := &ConversionReport{}
 
-	// Set basic properties
-	warpTheme.Background = getColorOrDefault(vscodeTheme.Colors, "editor.background", "#1e1e1e")
-	warpTheme.Foreground = getColorOrDefault(vscodeTheme.Colors, "editor.foreground", "#d4d4d4")
-	
-	// Try to find an accent color from various VS Code color keys
-	accentKeys := []string{
-		"focusBorder",
-		"button.background",
-		"progressBar.background",
-		"textLink.foreground",
-		"editorCursor.foreground",
-		"terminal.ansiBlue",
+	profile := opts.Mapping
+	if profile == nil {
+		profile = defaultMappingProfile()
 	}
-	
-	warpTheme.Accent = findFirstColor(vscodeTheme.Colors, accentKeys, "#007acc")
-	
-	// Determine if theme is dark or light and set details accordingly
-	if vscodeTheme.Type == "light" {
-		warpTheme.Details = "lighter"
-	} else {
-		warpTheme.Details = "darker"
+	defaults := defaultMappingProfile()
+	backgroundSources, foregroundSources := profile.Background, profile.Foreground
+	if len(opts.BackgroundKeys) > 0 {
+		backgroundSources = withKeys(backgroundSources, opts.BackgroundKeys)
 	}
+	if len(opts.ForegroundKeys) > 0 {
+		foregroundSources = withKeys(foregroundSources, opts.ForegroundKeys)
+	}
+
+	// Set basic properties. A translucent background shows the workbench behind it,
+	// every other color is composited over the resulting background.
+	var background, accent string
+	background, report.BackgroundKey = mapColor("background", backgroundSources, defaults.Background, vscodeTheme, vscodeTheme.Colors, workbenchBase(normalizeThemeType(vscodeTheme.Type)), report)
+	warpTheme.Foreground, report.ForegroundKey = mapColor("foreground", foregroundSources, defaults.Foreground, vscodeTheme, vscodeTheme.Colors, background, report)
+	accent, _ = mapColor("accent", profile.Accent, defaults.Accent, vscodeTheme, vscodeTheme.Colors, background, report)
+	warpTheme.Background, warpTheme.Accent = solidColor(background), solidColor(accent)
+
+	// Fade the background into the surfaces around the editor when asked to
+	if opts.Gradient != "" {
+		if gradient, ok := deriveBackgroundGradient(vscodeTheme.Colors, background, opts.Gradient); ok {
+			warpTheme.Background = gradient
+		} else {
+			report.warnf("gradient: none of %s differs from the background, keeping it solid", strings.Join(gradientSurfaceKeys, ", "))
+		}
+	}
+
+	// Determine if theme is dark or light from the background itself and set details accordingly
+	warpTheme.Details = detailsForBackground(background, normalizeThemeType(vscodeTheme.Type))
+
+	// Name the theme as Warp's theme picker should show it
+	warpTheme.Name = FormatThemeName(opts.NameTemplate, vscodeTheme.Name, extensionMetadata)
 
 	// Set attribution based on extension metadata
 	warpTheme.BasedOn = FormatBasedOnAttribution(vscodeTheme.Name, extensionMetadata)
 
-	// Convert terminal colors
-	warpTheme.TerminalColors = convertTerminalColors(vscodeTheme.Colors)
-
-	return warpTheme, nil
-}
-
-// convertTerminalColors maps VS Code terminal colors to Warp format
-func convertTerminalColors(colors map[string]string) TerminalColors {
-	return TerminalColors{
-		Normal: ColorPalette{
-			Black:   getColorOrDefault(colors, "terminal.ansiBlack", "#1e1e1e"),
-			Red:     getColorOrDefault(colors, "terminal.ansiRed", "#f44747"),
-			Green:   getColorOrDefault(colors, "terminal.ansiGreen", "#6a9955"),
-			Yellow:  getColorOrDefault(colors, "terminal.ansiYellow", "#dcdcaa"),
-			Blue:    getColorOrDefault(colors, "terminal.ansiBlue", "#569cd6"),
-			Magenta: getColorOrDefault(colors, "terminal.ansiMagenta", "#c586c0"),
-			Cyan:    getColorOrDefault(colors, "terminal.ansiCyan", "#9cdcfe"),
-			White:   getColorOrDefault(colors, "terminal.ansiWhite", "#d4d4d4"),
-		},
-		Bright: ColorPalette{
-			Black:   getColorOrDefault(colors, "terminal.ansiBrightBlack", "#686868"),
-			Red:     getColorOrDefault(colors, "terminal.ansiBrightRed", "#f44747"),
-			Green:   getColorOrDefault(colors, "terminal.ansiBrightGreen", "#6a9955"),
-			Yellow:  getColorOrDefault(colors, "terminal.ansiBrightYellow", "#dcdcaa"),
-			Blue:    getColorOrDefault(colors, "terminal.ansiBrightBlue", "#569cd6"),
-			Magenta: getColorOrDefault(colors, "terminal.ansiBrightMagenta", "#c586c0"),
-			Cyan:    getColorOrDefault(colors, "terminal.ansiBrightCyan", "#9cdcfe"),
-			White:   getColorOrDefault(colors, "terminal.ansiBrightWhite", "#ffffff"),
-		},
-	}
-}
-
-// getColorOrDefault returns a color from the map or a default value
-func getColorOrDefault(colors map[string]string, key, defaultValue string) string {
-	if color, exists := colors[key]; exists && color != "" {
-		return cleanColor(color)
-	}
-	return defaultValue
-}
-
-// findFirstColor finds the first available color from a list of keys
-func findFirstColor(colors map[string]string, keys []string, defaultValue string) string {
-	for _, key := range keys {
-		if color, exists := colors[key]; exists && color != "" {
-			return cleanColor(color)
+	// Convert terminal colors, filling the gaps from the theme's own colors when requested
+	terminalColors := make(map[string]string, len(vscodeTheme.Colors))
+	for key, color := range vscodeTheme.Colors {
+		terminalColors[key] = color
+	}
+	if opts.DeriveANSI {
+		for key, color := range deriveANSIColors(vscodeTheme, background) {
+			terminalColors[key] = color
 		}
 	}
-	return defaultValue
+	warpTheme.TerminalColors = convertTerminalColors(profile, defaults, vscodeTheme, terminalColors, background, report)
+
+	return warpTheme, report, nil
 }
 
-// cleanColor removes any alpha channel or invalid characters from hex colors
-func cleanColor(color string) string {
-	// Remove any whitespace
-	color = strings.TrimSpace(color)
-	
-	// Ensure it starts with #
-	if !strings.HasPrefix(color, "#") {
-		return color
+// convertTerminalColors maps VS Code terminal colors to Warp format through the profile,
+// compositing translucent colors over background. Slots none of whose sources match are derived
+// from their bright or normal counterpart as mapped, and failing that get VS Code's Dark+ colors.
+func convertTerminalColors(profile, defaults *MappingProfile, vscodeTheme *VSCodeTheme, colors map[string]string, background string, report *ConversionReport) TerminalColors {
+	var terminalColors TerminalColors
+	palettes := []struct {
+		name              string
+		palette           *ColorPalette
+		sources, fallback map[string][]ColorSource
+		literals          []string
+	}{
+		{"normal", &terminalColors.Normal, profile.TerminalColors.Normal, defaults.TerminalColors.Normal, defaultNormalColors},
+		{"bright", &terminalColors.Bright, profile.TerminalColors.Bright, defaults.TerminalColors.Bright, defaultBrightColors},
 	}
-	
-	// Remove alpha channel if present (8-character hex codes)
-	if len(color) == 9 {
-		return color[:7]
+	for _, p := range palettes {
+		for i, slot := range p.palette.slots() {
+			name := strings.ToLower(ansiColorNames[i])
+			field := "terminal_colors." + p.name + "." + name
+			color, _, ok := resolveColor(field, p.sources[name], vscodeTheme, colors, background, report)
+			if !ok && !sameSources(p.sources[name], p.fallback[name]) {
+				report.warnf("%s: no mapped source matched, using the default mapping", field)
+				color, _, _ = resolveColor(field, p.fallback[name], vscodeTheme, colors, background, report)
+			}
+			*slot = color
+		}
 	}
-	
-	// Handle short hex codes
-	if len(color) == 4 {
-		// Convert #abc to #aabbcc
-		return fmt.Sprintf("#%c%c%c%c%c%c", color[1], color[1], color[2], color[2], color[3], color[3])
+
+	deriveANSIVariants(&terminalColors, background)
+
+	for _, p := range palettes {
+		for i, slot := range p.palette.slots() {
+			if *slot == "" {
+				*slot = p.literals[i]
+			}
+		}
 	}
-	
-	return color
+	return terminalColors
+}
+
+// mapColor resolves a Warp color from its profile sources and returns it with the source it came
+// from. When none of them yields a color, the default profile's sources are used instead.
+func mapColor(field string, sources, defaults []ColorSource, vscodeTheme *VSCodeTheme, colors map[string]string, background string, report *ConversionReport) (string, string) {
+	if color, source, ok := resolveColor(field, sources, vscodeTheme, colors, background, report); ok {
+		return color, source
+	}
+	report.warnf("%s: no mapped source matched, using the default mapping", field)
+	color, source, _ := resolveColor(field, defaults, vscodeTheme, colors, background, report)
+	return color, source
 }
 
-// SaveWarpTheme saves a Warp theme to the appropriate directory
-func SaveWarpTheme(theme *WarpTheme, name string) error {
-	themesDir, err := getWarpThemesPath()
+// Backgrounds within this distance of the luminance where black and white text contrast equally
+// could go either way, so the theme's declared type decides
+const ambiguousLuminance = 0.05
+
+// detailsForBackground returns Warp's details value, "darker" or "lighter", for a background.
+// The background's relative luminance decides; themeType ("dark" or "light") is only a hint
+// for backgrounds close to the middle.
+func detailsForBackground(background, themeType string) string {
+	dark := themeType != "light"
+	if color, err := parseColor(background); err == nil {
+		luminance := color.relativeLuminance()
+		if math.Abs(luminance-midLuminance) > ambiguousLuminance || themeType == "" {
+			dark = luminance < midLuminance
+		}
+	}
+
+	if dark {
+		return "darker"
+	}
+	return "lighter"
+}
+
+// workbenchBase returns the surface VS Code shows through a translucent editor background
+func workbenchBase(themeType string) string {
+	if themeType == "light" {
+		return "#ffffff"
+	}
+	return "#000000"
+}
+
+// cleanColor validates a VS Code color and normalizes it to a lowercase #rrggbb Warp color.
+// Colors with an alpha channel are composited over background so they look as they do in VS Code.
+func cleanColor(color, background string) (string, error) {
+	parsed, err := parseColor(color)
 	if err != nil {
-		return fmt.Errorf("failed to get Warp themes directory: %w", err)
+		return "", err
 	}
-	
+
+	// Blend away the alpha channel if present
+	if parsed.A < 1 {
+		base, err := parseColor(background)
+		if err != nil {
+			return "", fmt.Errorf("invalid background for blending: %w", err)
+		}
+		parsed = parsed.over(base)
+	}
+
+	return parsed.hex(), nil
+}
+
+// SaveWarpTheme saves a Warp theme to themesDir, or to Warp's themes directory when themesDir is empty,
+// and returns the path of the written file. A background image is copied next to the theme and
+// referenced by its relative path. The file is replaced atomically; a previous version with other
+// contents is backed up first and the backup's path returned.
+func SaveWarpTheme(theme *WarpTheme, name string, themesDir string) (string, string, error) {
+	themesDir, err := resolveThemesDir(themesDir)
+	if err != nil {
+		return "", "", err
+	}
+
 	// Create themes directory if it doesn't exist
 	if err := os.MkdirAll(themesDir, 0755); err != nil {
-		return fmt.Errorf("failed to create themes directory: %w", err)
+		return "", "", fmt.Errorf("failed to create themes directory: %w", err)
 	}
 
-	// Clean the name for filename
-	filename := cleanFilename(name) + ".yaml"
-	themePath := filepath.Join(themesDir, filename)
+	themePath := filepath.Join(themesDir, warpThemeFilename(name))
+
+	if theme.BackgroundImage != nil {
+		imagePath, err := copyBackgroundImage(theme.BackgroundImage.Path, themesDir, name)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to copy background image: %w", err)
+		}
+		saved := *theme
+		saved.BackgroundImage = &BackgroundImage{Path: imagePath, Opacity: theme.BackgroundImage.Opacity}
+		theme = &saved
+	}
 
 	// Marshal to YAML
 	yamlData, err := yaml.Marshal(theme)
 	if err != nil {
-		return fmt.Errorf("failed to marshal theme to YAML: %w", err)
+		return "", "", fmt.Errorf("failed to marshal theme to YAML: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(themePath, yamlData, 0644); err != nil {
-		return fmt.Errorf("failed to write theme file: %w", err)
+	// Keep the version being replaced, then swap the new one in
+	backupPath, err := backupThemeFile(themePath, yamlData)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to back up existing theme file: %w", err)
+	}
+	if err := writeFileAtomic(themePath, yamlData, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write theme file: %w", err)
 	}
 
-	return nil
+	return themePath, backupPath, nil
+}
+
+// warpThemeFilename returns the YAML filename used for a theme with the given name
+func warpThemeFilename(name string) string {
+	return cleanFilename(name) + ".yaml"
 }
 
 // cleanFilename removes or replaces characters that aren't suitable for filenames
@@ -203,6 +309,26 @@ func cleanFilename(name string) string {
 	return name
 }
 
+// defaultNameTemplate names converted themes after their VS Code label
+const defaultNameTemplate = "{theme}"
+
+// FormatThemeName fills a name template with the theme label for {theme} and the extension's
+// display name for {extension}. An empty template names the theme after its label.
+func FormatThemeName(template, themeName string, metadata *ExtensionMetadata) string {
+	if template == "" {
+		template = defaultNameTemplate
+	}
+	extensionName := ""
+	if metadata != nil {
+		extensionName = metadata.DisplayName
+		if extensionName == "" {
+			extensionName = metadata.Name
+		}
+	}
+	name := strings.NewReplacer("{theme}", themeName, "{extension}", extensionName).Replace(template)
+	return strings.TrimSpace(name)
+}
+
 // FormatBasedOnAttribution creates a "based on" attribution string from extension metadata and theme name
 func FormatBasedOnAttribution(themeName string, metadata *ExtensionMetadata) string {
 	if metadata == nil {